@@ -1,14 +1,39 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/hitman/pkg/registry"
 	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+const (
+	// exit code of apply interrupted by SIGINT/SIGTERM
+	exitCodeInterrupted = 130
+)
+
+type (
+	// live state of resource taken before apply, manifest is empty for resources missing in cluster
+	resourceSnapshot struct {
+		resource kubectl.Resource
+		manifest []byte
+	}
+
+	// cluster state recorded before apply, used to revert failed rollout
+	rollOutState struct {
+		revisionList map[string]int
+		snapshotList []resourceSnapshot
+	}
+)
+
 var (
 	applyCmd = &cobra.Command{
 		Use:   "apply",
@@ -16,74 +41,334 @@ var (
 		Long:  `Apply new configuration to Kubernetes cluster and monitor release delivery`,
 		RunE:  applyCmdHandler,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if configurationYaml == "" {
+			if len(configurationSourceList) == 0 {
 				return errors.New("mandatory configuration spec filename is not provided")
 			}
+			if pinDigests && registryURLFlag == "" {
+				return errors.New("--pin-digests requires --registry-url")
+			}
+			if dryRunMode != "" && dryRunMode != kubectl.DryRunClient && dryRunMode != kubectl.DryRunServer {
+				return fmt.Errorf("invalid dry-run mode %q, must be \"client\" or \"server\"", dryRunMode)
+			}
 			return nil
 		},
 	}
 
-	configurationYaml string
+	configurationYaml string // merged configuration file
 	clusterTimeout    time.Duration
+	maxRestarts       int
+	watchRollout      bool
+	pollInterval      time.Duration
+	gracePeriod       time.Duration
+	deleteNew         bool
+	dryRunMode        string
+	pinDigests        bool
+
+	errRollOutInterrupted = errors.New("rollout interrupted")
 )
 
 func init() {
-	applyCmd.Flags().StringVarP(&configurationYaml, "configuration", "f", "", "Rollout configuration spec file (yaml), mandatory")
-	applyCmd.MarkFlagRequired("configuration")
-	applyCmd.MarkFlagFilename("configuration", "yml", "yaml")
+	addConfigurationFlags(applyCmd, "Rollout configuration spec files, directories or \"-\" for stdin (yaml), mandatory")
 
 	applyCmd.Flags().DurationVarP(&clusterTimeout, "rollout-timeout", "t", 3*time.Minute, "Rollout timeout")
+	applyCmd.Flags().IntVar(&maxRestarts, "max-restarts", 3, "Abort rollout when container of new pod restarted more times, 0 to disable")
+	applyCmd.Flags().BoolVar(&watchRollout, "watch", true, "Watch cluster changes during rollout, polling is used if watch is disabled or interrupted")
+	applyCmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "Delay between cluster state checks in polling mode")
+	applyCmd.Flags().DurationVar(&gracePeriod, "grace-period", 1*time.Minute, "Time given to undo rollout after SIGINT/SIGTERM")
+	applyCmd.Flags().BoolVar(&deleteNew, "delete-new", false, "Delete Deployments, StatefulSets and DaemonSets created by failed rollout")
+	applyCmd.Flags().StringVar(&dryRunMode, "dry-run", "", "Only show what would be applied, \"client\" or \"server\", rollout is not monitored")
+	applyCmd.Flags().Lookup("dry-run").NoOptDefVal = kubectl.DryRunClient
+	applyCmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "Pin images hosted by --registry-url to manifest digests of their tags")
+	applyCmd.Flags().StringVarP(&registryURLFlag, "registry-url", "r", "", "Registry URL to check images of configuration exist before apply (e.g. \"https://registry.example.com:5000/\")")
 	RootCmd.AddCommand(applyCmd)
 }
 
+// human readable resource kind, used in reports
+func kindTitle(kind string) string {
+	switch kind {
+	case kubectl.KindDeployment:
+		return "Deployment"
+	case kubectl.KindStatefulSet:
+		return "StatefulSet"
+	case kubectl.KindDaemonSet:
+		return "DaemonSet"
+	case kubectl.KindJob:
+		return "Job"
+	}
+	return kind
+}
+
+// sleep, unless context is done earlier
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch live resource from cluster, nil is returned for resource missing in cluster
+func getLiveResource(ctx context.Context, cluster kubectl.Cluster, namespace, kind, name string) (kubectl.KubeResourceInterface, error) {
+	r, err := cluster.GetResource(ctx, namespace, kind, name)
+	if kubectl.IsNotFound(err) {
+		return nil, nil
+	}
+
+	return r, err
+}
+
+// fetch live manifest of resource from cluster, nil is returned for resource missing in cluster
+func getLiveManifest(ctx context.Context, cluster kubectl.Cluster, r kubectl.Resource) ([]byte, error) {
+	manifest, err := cluster.GetManifest(ctx, r.GetNamespace(), r.GetKind(), r.GetName())
+	if kubectl.IsNotFound(err) {
+		return nil, nil
+	}
+
+	return manifest, err
+}
+
 //
-func getRolledList(specList *[]kubectl.Deployment, skipMissing bool) (*[]kubectl.Deployment, error) {
-	rolledList := make([]kubectl.Deployment, 0)
+func getRolledList(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface, skipMissing bool) (*[]kubectl.RolloutResourceInterface, error) {
+	rolledList := make([]kubectl.RolloutResourceInterface, 0)
 	for _, spec := range *specList {
 		// fetch data from cluster
-		r, err := kubectl.CommandDeploymentInfo(spec.GetNamespace(), spec.GetName()).RunAndParseFirst()
+		r, err := getLiveResource(ctx, cluster, spec.GetNamespace(), spec.GetKind(), spec.GetName())
 		if err != nil && !skipMissing {
 			return nil, err
 		}
+		if r == nil && !skipMissing {
+			return nil, fmt.Errorf("%s %s not found", spec.GetKind(), spec.GetKey())
+		}
 		if r == nil {
 			continue
 		}
 
-		// convert to Deployment
-		d, err := r.ToDeployment()
-		if err != nil && !skipMissing {
-			return nil, err
+		// convert to rollout resource
+		d, ok := r.(kubectl.RolloutResourceInterface)
+		if !ok && !skipMissing {
+			return nil, fmt.Errorf("%s can't be monitored", r.GetKind())
 		}
 		if d == nil {
 			continue
 		}
 
-		rolledList = append(rolledList, *d)
+		rolledList = append(rolledList, d)
 	}
 	return &rolledList, nil
 }
 
+// unique key of resource among resources of all kinds
+func rolloutKey(d kubectl.RolloutResourceInterface) string {
+	return d.GetKind() + "/" + d.GetKey()
+}
+
+// current revision of resource, Deployment revision is kept in annotation,
+// StatefulSet and DaemonSet revision is the highest revision of ControllerRevisions
+func getRevision(ctx context.Context, cluster kubectl.Cluster, d kubectl.RolloutResourceInterface) (int, error) {
+	switch r := d.(type) {
+	case *kubectl.Deployment:
+		return r.GetRevision(), nil
+
+	case *kubectl.StatefulSet, *kubectl.DaemonSet:
+		clist, err := cluster.ListControllerRevisions(ctx, d.GetNamespace(), d.GetPodSelector())
+		if err != nil {
+			return 0, err
+		}
+
+		revision := 0
+		for _, c := range clist {
+			if c.Revision > revision {
+				revision = c.Revision
+			}
+		}
+		return revision, nil
+	}
+
+	// jobs have no revisions
+	return 0, nil
+}
+
+// Record revision of every resource before apply, resources missing in cluster are not recorded
+func getRevisionList(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface) (map[string]int, error) {
+	fmt.Println("==> Recording current revisions...")
+	revisionList := make(map[string]int)
+	for _, spec := range *specList {
+		r, err := getLiveResource(ctx, cluster, spec.GetNamespace(), spec.GetKind(), spec.GetName())
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			fmt.Printf("===> %s: %s - new resource\n", kindTitle(spec.GetKind()), spec.GetKey())
+			continue
+		}
+
+		d, ok := r.(kubectl.RolloutResourceInterface)
+		if !ok {
+			return nil, fmt.Errorf("%s can't be monitored", r.GetKind())
+		}
+
+		revision, err := getRevision(ctx, cluster, d)
+		if err != nil {
+			return nil, err
+		}
+
+		revisionList[rolloutKey(d)] = revision
+		fmt.Printf("===> %s: %s, Revision: %d\n", kindTitle(d.GetKind()), d.GetKey(), revision)
+	}
+
+	return revisionList, nil
+}
+
+// Take snapshot of every resource in configuration, which is not monitored by rollout
+// (Services, ConfigMaps, Secrets, etc.), resources missing in cluster are marked as new
+func getSnapshotList(ctx context.Context, cluster kubectl.Cluster) ([]resourceSnapshot, error) {
+	fmt.Println("==> Taking snapshot of resources...")
+	resourceList, err := kubectl.ParseLocalFileResources(configurationYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotList := make([]resourceSnapshot, 0)
+	for _, r := range resourceList {
+		switch r.GetKind() {
+		case kubectl.KindDeployment, kubectl.KindStatefulSet, kubectl.KindDaemonSet, kubectl.KindJob:
+			continue
+		}
+
+		manifest, err := getLiveManifest(ctx, cluster, r)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			fmt.Printf("===> %s: %s - new resource\n", r.Kind, r.GetKey())
+			snapshotList = append(snapshotList, resourceSnapshot{resource: r})
+			continue
+		}
+
+		fmt.Printf("===> %s: %s - snapshot taken\n", r.Kind, r.GetKey())
+		snapshotList = append(snapshotList, resourceSnapshot{resource: r, manifest: manifest})
+	}
+
+	return snapshotList, nil
+}
+
+// Record everything required to revert rollout, nothing is changed in cluster yet
+func getRollOutState(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface) (*rollOutState, error) {
+	revisionList, err := getRevisionList(ctx, cluster, specList)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotList, err := getSnapshotList(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rollOutState{
+		revisionList: revisionList,
+		snapshotList: snapshotList,
+	}, nil
+}
+
+// build selector of pods created by the latest revision of resource
+func getNewPodSelector(ctx context.Context, cluster kubectl.Cluster, d kubectl.RolloutResourceInterface) ([]string, error) {
+	selector := d.GetPodSelector()
+
+	switch r := d.(type) {
+	case *kubectl.Deployment:
+		// new pods are owned by replica set with the highest revision
+		rlist, err := cluster.ListReplicaSets(ctx, r.GetNamespace(), r.GetSelector())
+		if err != nil {
+			return nil, err
+		}
+
+		var newReplicaSet *kubectl.ReplicaSet
+		for _, rs := range rlist {
+			if newReplicaSet == nil || rs.GetRevision() > newReplicaSet.GetRevision() {
+				rsCopy := rs
+				newReplicaSet = &rsCopy
+			}
+		}
+
+		if newReplicaSet != nil && newReplicaSet.GetPodTemplateHash() != "" {
+			selector = append(selector, fmt.Sprintf("%s=%s", kubectl.LabelPodTemplateHash, newReplicaSet.GetPodTemplateHash()))
+		}
+
+	case *kubectl.StatefulSet:
+		if r.Status.UpdateRevision != "" {
+			selector = append(selector, fmt.Sprintf("%s=%s", kubectl.LabelControllerRevisionHash, r.Status.UpdateRevision))
+		}
+
+	case *kubectl.DaemonSet:
+		// DaemonSet doesn't expose update revision in status, new pods belong to the highest ControllerRevision
+		clist, err := cluster.ListControllerRevisions(ctx, r.GetNamespace(), r.GetPodSelector())
+		if err != nil {
+			return nil, err
+		}
+
+		var newRevision *kubectl.ControllerRevision
+		for _, c := range clist {
+			if newRevision == nil || c.Revision > newRevision.Revision {
+				cCopy := c
+				newRevision = &cCopy
+			}
+		}
+
+		if newRevision != nil && newRevision.GetRevisionHash() != "" {
+			selector = append(selector, fmt.Sprintf("%s=%s", kubectl.LabelControllerRevisionHash, newRevision.GetRevisionHash()))
+		}
+	}
+
+	// Job pods are all new by definition
+	return selector, nil
+}
+
+// find first pod of latest revision, which will never become ready
+func detectFailedPod(ctx context.Context, cluster kubectl.Cluster, d kubectl.RolloutResourceInterface) (*kubectl.Pod, string, error) {
+	selector, err := getNewPodSelector(ctx, cluster, d)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plist, err := cluster.ListPods(ctx, d.GetNamespace(), selector)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, pod := range plist {
+		if reason := pod.GetFailureReason(maxRestarts); reason != "" {
+			return &pod, reason, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
 // Load and check configuration (yaml file), parse and return all
-// deployments defined in configuration file
-func initRollOut() (*[]kubectl.Deployment, error) {
+// deployments, statefulsets, daemonsets and jobs defined in configuration file
+func initRollOut() (*[]kubectl.RolloutResourceInterface, error) {
 	// parsing provided configuration
 	fullResourceList, err := kubectl.ParseLocalFile(configurationYaml)
 	if err != nil {
 		return nil, err
 	}
 
-	// filtering deployments, if no deployments found it's an error
-	newConfigurationList := fullResourceList.FilteredByKind(kubectl.KindDeployment).ToDeploymentList()
+	// filtering rollout resources, if nothing found it's an error
+	newConfigurationList := fullResourceList.ToRolloutList()
 	if len(newConfigurationList) == 0 {
-		return nil, errors.New("no Deployment resources found in configuration")
+		return nil, errors.New("no Deployment, StatefulSet, DaemonSet or Job resources found in configuration")
 	}
 
 	return &newConfigurationList, nil
 }
 
 // Start deploy process / apply new configuration to cluster and display output
-func applyRollOut(specList *[]kubectl.Deployment) error {
-	stdout, err := kubectl.CommandApply(configurationYaml).RunPlain()
+func applyRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface) error {
+	stdout, err := cluster.Apply(ctx, configurationYaml)
 	fmt.Println(string(stdout)) // in case of error, display output
 	if err != nil {
 		return err
@@ -92,82 +377,251 @@ func applyRollOut(specList *[]kubectl.Deployment) error {
 	return nil
 }
 
-// Monitor configuration delivery, all unavailable replicas of each deployment
-// should be 0. Wait until timeout.
-func monitorRollOut(specList *[]kubectl.Deployment) (bool, error) {
+// Monitor configuration delivery, every resource should report ready state
+// and every job should complete. Wait until timeout or until any resource or new pod fails.
+func monitorRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface) (bool, error) {
 	fmt.Printf("==> Starting rollout monitoring, rollout timeout: %v\n", clusterTimeout)
 	willExpireAt := time.Now().Add(clusterTimeout)
 
+	if watchRollout {
+		isRolledOut, isDone, err := watchRollOut(ctx, cluster, specList, willExpireAt)
+		if isDone || err != nil {
+			return isRolledOut, err
+		}
+		fmt.Printf("==> Watch is not available, polling every %v\n", pollInterval)
+	}
+
+	return pollRollOut(ctx, cluster, specList, willExpireAt)
+}
+
+// Fetch state of every resource with fixed interval
+func pollRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface, willExpireAt time.Time) (bool, error) {
 	for {
 		// make initial delay..
-		time.Sleep(5 * time.Second)
-		rolledList, err := getRolledList(specList, true)
+		if err := sleepContext(ctx, pollInterval); err != nil {
+			return false, err
+		}
+
+		rolledList, err := getRolledList(ctx, cluster, specList, true)
 		if err != nil {
 			return false, err
 		}
 
-		// 1) timeout reached?
+		// timeout reached?
 		if time.Now().After(willExpireAt) {
 			break
 		}
 
-		// 2) every deployment defined in spec registered in cluster?
-		if len(*specList) != len(*rolledList) {
-			fmt.Printf("===> Waiting for deployment registration, %d to go...\n", len(*specList)-len(*rolledList))
-			continue
+		isRolledOut, isFailed, err := checkRollOut(ctx, cluster, specList, rolledList, true)
+		if err != nil {
+			return false, err
+		}
+		if isFailed || isRolledOut {
+			return isRolledOut, nil
 		}
+	}
+
+	// timeout reached, aborting...
+	fmt.Println("===> Rollout failed!")
+	return false, nil
+}
 
-		// 3) every deployment is rolled out?
-		isRolledOut := true
-		for _, d := range *rolledList {
-			fmt.Printf("===> Deployment: %s, %s\n", d.GetKey(), d.GetStatusString())
-			if !d.IsReady() {
-				isRolledOut = false
+// React on resource and pod changes streamed by cluster, state is checked on every change.
+// isDone is false when watch can't be started or was interrupted, caller should fall back to polling.
+// Rolled resource deleted while being watched is an error, rollout can't be completed.
+func watchRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface, willExpireAt time.Time) (isRolledOut bool, isDone bool, err error) {
+	// single watch for every kind in every namespace, pods are watched to detect failures
+	watchKeyList := make([]string, 0)
+	watchKindMap := make(map[string][2]string)
+	for _, spec := range *specList {
+		for _, kind := range []string{spec.GetKind(), kubectl.KindPod} {
+			key := fmt.Sprintf("%s/%s", spec.GetNamespace(), kind)
+			if _, ok := watchKindMap[key]; !ok {
+				watchKeyList = append(watchKeyList, key)
+				watchKindMap[key] = [2]string{spec.GetNamespace(), kind}
 			}
 		}
+	}
 
-		// 4) if rolled out, stop..
-		if isRolledOut {
-			fmt.Println("==> Rollout done!")
-			return true, nil
+	// merge every stream into single channel
+	events := make(chan kubectl.WatchEvent)
+	interrupted := make(chan struct{}, len(watchKeyList))
+	done := make(chan struct{})
+	watchList := make([]kubectl.ResourceWatch, 0)
+	defer func() {
+		close(done)
+		for _, w := range watchList {
+			w.Stop()
+		}
+	}()
+
+	for _, key := range watchKeyList {
+		w, err := cluster.Watch(ctx, watchKindMap[key][0], watchKindMap[key][1])
+		if err != nil {
+			fmt.Printf("===> Watch failed: %s\n", err)
+			return false, false, nil
 		}
+		watchList = append(watchList, w)
+
+		go func(w kubectl.ResourceWatch) {
+			for event := range w.ResultChan() {
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+			interrupted <- struct{}{}
+		}(w)
 	}
 
-	// timeout reached, aborting...
-	fmt.Println("===> Rollout failed!")
-	return false, nil
+	// latest known state of every spec resource
+	stateMap := make(map[string]kubectl.RolloutResourceInterface)
+	specKeyMap := make(map[string]bool)
+	for _, spec := range *specList {
+		specKeyMap[rolloutKey(spec)] = true
+	}
+
+	timeout := time.NewTimer(time.Until(willExpireAt))
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, true, ctx.Err()
+
+		case <-timeout.C:
+			fmt.Println("===> Rollout failed!")
+			return false, true, nil
+
+		case <-interrupted:
+			fmt.Println("===> Watch interrupted")
+			return false, false, nil
+
+		case event := <-events:
+			checkPods := false
+			switch o := event.Resource.(type) {
+			case *kubectl.Pod:
+				// old pods are deleted during rollout, only failing pod is interesting
+				if event.Type == kubectl.WatchDeleted {
+					continue
+				}
+				checkPods = o.GetFailureReason(maxRestarts) != ""
+
+			case kubectl.RolloutResourceInterface:
+				key := rolloutKey(o)
+				if !specKeyMap[key] {
+					continue
+				}
+				if event.Type == kubectl.WatchDeleted {
+					return false, true, fmt.Errorf("%s %s was deleted during rollout", kindTitle(o.GetKind()), o.GetKey())
+				}
+				stateMap[key] = o
+			}
+
+			if !checkPods && len(stateMap) != len(*specList) {
+				continue
+			}
+
+			rolledList := make([]kubectl.RolloutResourceInterface, 0)
+			for _, spec := range *specList {
+				if d, ok := stateMap[rolloutKey(spec)]; ok {
+					rolledList = append(rolledList, d)
+				}
+			}
+
+			isRolledOut, isFailed, err := checkRollOut(ctx, cluster, specList, &rolledList, checkPods)
+			if err != nil {
+				return false, true, err
+			}
+			if isFailed || isRolledOut {
+				return isRolledOut, true, nil
+			}
+		}
+	}
+}
+
+// Check state of rolled resources, with checkPods new pods of not ready resources are inspected too
+func checkRollOut(ctx context.Context, cluster kubectl.Cluster, specList, rolledList *[]kubectl.RolloutResourceInterface, checkPods bool) (isRolledOut bool, isFailed bool, err error) {
+	// 1) every resource defined in spec registered in cluster?
+	if len(*specList) != len(*rolledList) {
+		fmt.Printf("===> Waiting for resource registration, %d to go...\n", len(*specList)-len(*rolledList))
+		return false, false, nil
+	}
+
+	// 2) every resource is rolled out?
+	isRolledOut = true
+	for _, d := range *rolledList {
+		fmt.Printf("===> %s: %s, %s\n", kindTitle(d.GetKind()), d.GetKey(), d.GetStatusString())
+		if d.IsReady() {
+			continue
+		}
+
+		isRolledOut = false
+		if f, ok := d.(kubectl.FailingResourceInterface); ok && f.IsFailed() {
+			fmt.Printf("===> %s: %s - %s\n", kindTitle(d.GetKind()), d.GetKey(), f.GetFailureReason())
+			isFailed = true
+			continue
+		}
+		if !checkPods {
+			continue
+		}
+
+		// crash looping or unschedulable pod will never become ready
+		pod, reason, err := detectFailedPod(ctx, cluster, d)
+		if err != nil {
+			return false, false, err
+		}
+		if pod != nil {
+			fmt.Printf("===> %s: %s, Pod: %s - %s\n", kindTitle(d.GetKind()), d.GetKey(), pod.GetKey(), reason)
+			isFailed = true
+		}
+	}
+
+	// 3) failed resource will never become ready, no reason to wait
+	if isFailed {
+		fmt.Println("===> Rollout failed!")
+		return false, true, nil
+	}
+
+	// 4) if rolled out, stop..
+	if isRolledOut {
+		fmt.Println("==> Rollout done!")
+	}
+
+	return isRolledOut, false, nil
 }
 
-// Finalize delivery process, either do nothing or display logs for each pod of each deployment
+// Finalize delivery process, either do nothing or display logs for each pod of each resource
 // in order to have information about broken delivery
-func finalizeRollOut(specList *[]kubectl.Deployment, isRolledOut bool) error {
+func finalizeRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface, state *rollOutState, isRolledOut bool) error {
 	// make small delay
-	time.Sleep(5 * time.Second)
+	if err := sleepContext(ctx, pollInterval); err != nil {
+		return err
+	}
 
-	// display logs for each pod attached to deployment list
+	// display logs for each pod attached to resource list
 	fmt.Println("==> Fetching logs...")
-	rolledList, err := getRolledList(specList, false)
+	rolledList, err := getRolledList(ctx, cluster, specList, false)
 	if err != nil {
 		return err
 	}
 
 	for _, d := range *rolledList {
-		// get list of pods connected to deployment
-		rlist, _ := kubectl.CommandPodListBySelector(d.GetNamespace(), d.GetPodSelector()).RunAndParse()
-		if rlist == nil {
-			continue
-		}
+		// get list of pods connected to resource
+		plist, _ := cluster.ListPods(ctx, d.GetNamespace(), d.GetPodSelector())
 
-		// display logs for each pod
-		plist := rlist.FilteredByKind(kubectl.KindPod).ToPodList()
+		// display logs for each pod, job pods are terminated when job is done
+		_, isJob := d.(*kubectl.Job)
 		for _, pod := range plist {
-			if pod.Status.Phase != kubectl.PodStatusRunning {
+			isTerminated := pod.Status.Phase == kubectl.PodStatusSucceeded || pod.Status.Phase == kubectl.PodStatusFailed
+			if pod.Status.Phase != kubectl.PodStatusRunning && !(isJob && isTerminated) {
 				continue
 			}
 
 			for _, container := range pod.Spec.Containers {
-				stdout, err := kubectl.CommandPodLogs(pod.GetNamespace(), pod.GetName(), container.Name).RunPlain()
-				fmt.Printf("===> Deployment: %s, Pod: %s, Container: %s:\n", d.GetKey(), pod.GetKey(), container.Name)
+				stdout, err := cluster.Logs(ctx, pod.GetNamespace(), pod.GetName(), container.Name)
+				fmt.Printf("===> %s: %s, Pod: %s, Container: %s:\n", kindTitle(d.GetKind()), d.GetKey(), pod.GetKey(), container.Name)
 				fmt.Println(string(stdout))
 				if err != nil {
 					return err
@@ -182,56 +636,324 @@ func finalizeRollOut(specList *[]kubectl.Deployment, isRolledOut bool) error {
 		return nil
 	}
 
-	// error registered, if deployment has > 1 replica sets, roll it back to previous revision
+	// error registered, if resource has previous revision, roll it back
 	fmt.Println("==> Rollout failed, starting undo process...")
-	for _, d := range *rolledList {
-		// get list of replica sets connected to deployment
-		rlist, err := kubectl.CommandReplicaSetListBySelector(d.GetNamespace(), d.GetSelector()).RunAndParse()
+	return revertRollOut(ctx, cluster, rolledList, state)
+}
+
+// Restore resources from snapshots and roll back monitored resources
+func revertRollOut(ctx context.Context, cluster kubectl.Cluster, rolledList *[]kubectl.RolloutResourceInterface, state *rollOutState) error {
+	if err := restoreSnapshots(ctx, cluster, state.snapshotList); err != nil {
+		return err
+	}
+
+	return undoRollOut(ctx, cluster, rolledList, state.revisionList)
+}
+
+// Apply snapshots back to cluster, resources created by rollout are deleted,
+// except namespaces, as they may contain other resources
+func restoreSnapshots(ctx context.Context, cluster kubectl.Cluster, snapshotList []resourceSnapshot) error {
+	manifestList := make([][]byte, 0)
+	for _, snapshot := range snapshotList {
+		if snapshot.manifest != nil {
+			manifestList = append(manifestList, snapshot.manifest)
+		}
+	}
+
+	if len(manifestList) > 0 {
+		file, err := ioutil.TempFile("", "fuse-snapshot")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(file.Name())
+
+		for _, manifest := range manifestList {
+			if _, err := file.Write([]byte("---\n")); err != nil {
+				file.Close()
+				return err
+			}
+			if _, err := file.Write(manifest); err != nil {
+				file.Close()
+				return err
+			}
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+
+		stdout, err := cluster.Apply(ctx, file.Name())
+		fmt.Println(string(stdout))
 		if err != nil {
 			return err
 		}
+	}
+
+	for _, snapshot := range snapshotList {
+		r := snapshot.resource
+		if snapshot.manifest != nil {
+			fmt.Printf("===> %s: %s - restored from snapshot\n", r.Kind, r.GetKey())
+			continue
+		}
+
+		if r.GetKind() == kubectl.KindNamespace {
+			fmt.Printf("===> %s: %s - created by rollout, left as is\n", r.Kind, r.GetKey())
+			continue
+		}
+
+		stdout, err := cluster.Delete(ctx, r.GetNamespace(), r.GetKind(), r.GetName())
+		fmt.Printf("===> %s: %s - created by rollout, deleted\n", r.Kind, r.GetKey())
+		fmt.Println(string(stdout))
+		if err != nil && !kubectl.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Roll back every resource to revision recorded before apply, resources created
+// by rollout are deleted if requested, jobs are skipped
+func undoRollOut(ctx context.Context, cluster kubectl.Cluster, rolledList *[]kubectl.RolloutResourceInterface, revisionList map[string]int) error {
+	for _, d := range *rolledList {
+		if d.GetKind() == kubectl.KindJob {
+			fmt.Printf("===> %s: %s - jobs can't be rolled back\n", kindTitle(d.GetKind()), d.GetKey())
+			continue
+		}
 
-		// if deployment has previous configuration - perform "rollout undo"
-		// otherwise - do nothing...
-		if len(rlist) > 1 {
-			stdout, err := kubectl.CommandRollback(d.GetNamespace(), d.GetKind(), d.GetName()).RunPlain()
-			fmt.Printf("===> Deployment: %s - rolled back to previous release\n", d.GetKey())
+		// resource didn't exist before apply, nothing to roll back to
+		revision, isExisted := revisionList[rolloutKey(d)]
+		if !isExisted {
+			if !deleteNew {
+				fmt.Printf("===> %s: %s - created by rollout, left as is\n", kindTitle(d.GetKind()), d.GetKey())
+				continue
+			}
+
+			stdout, err := cluster.Delete(ctx, d.GetNamespace(), d.GetKind(), d.GetName())
+			fmt.Printf("===> %s: %s - created by rollout, deleted\n", kindTitle(d.GetKind()), d.GetKey())
 			fmt.Println(string(stdout))
 			if err != nil {
 				return err
 			}
+			continue
+		}
 
-		} else {
-			fmt.Printf("===> Deployment: %s - no rollback history available\n", d.GetKey())
+		if revision == 0 {
+			fmt.Printf("===> %s: %s - no rollback history available\n", kindTitle(d.GetKind()), d.GetKey())
+			continue
+		}
+
+		current, err := getRevision(ctx, cluster, d)
+		if err != nil {
+			return err
+		}
+		if current == revision {
+			fmt.Printf("===> %s: %s - revision %d is not changed\n", kindTitle(d.GetKind()), d.GetKey(), revision)
+			continue
+		}
+
+		stdout, err := cluster.Undo(ctx, d.GetNamespace(), d.GetKind(), d.GetName(), revision)
+		fmt.Printf("===> %s: %s - rolled back to revision %d\n", kindTitle(d.GetKind()), d.GetKey(), revision)
+		fmt.Println(string(stdout))
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// command handler
-func applyCmdHandler(cmd *cobra.Command, args []string) error {
-	var specList *[]kubectl.Deployment
+// run whole apply scenario, returns whether rollout was successful,
+// when context is done rollout is undone and errRollOutInterrupted is returned
+func runApply(ctx context.Context, cluster kubectl.Cluster) (bool, error) {
+	var specList *[]kubectl.RolloutResourceInterface
+	var state *rollOutState
 	var err error
 	var isRolledOut bool
 
 	// load and parse configuration spec
 	if specList, err = initRollOut(); err != nil {
-		return err
+		return false, err
+	}
+
+	// make sure every image can be pulled, nothing is changed yet
+	if imageRegistry != nil {
+		if err = checkImages(); err != nil {
+			return false, err
+		}
+	}
+
+	// tags are resolved once, so retagged image can't change deployed configuration
+	if imageRegistry != nil && pinDigests {
+		restore, err := pinImageDigests()
+		if err != nil {
+			return false, err
+		}
+		defer restore()
+	}
+
+	// remember revisions and snapshots to revert to, nothing is changed yet
+	if state, err = getRollOutState(ctx, cluster, specList); err != nil {
+		if ctx.Err() != nil {
+			return false, errRollOutInterrupted
+		}
+		return false, err
 	}
 
 	// apply configuration / start rollout
-	if err = applyRollOut(specList); err != nil {
-		return err
+	if err = applyRollOut(ctx, cluster, specList); err != nil {
+		return false, interruptRollOut(ctx, cluster, specList, state, err)
 	}
 
 	// monitor rollout
-	if isRolledOut, err = monitorRollOut(specList); err != nil {
+	if isRolledOut, err = monitorRollOut(ctx, cluster, specList); err != nil {
+		return false, interruptRollOut(ctx, cluster, specList, state, err)
+	}
+
+	// finalize deploy, failed rollout interrupted before undo is finished is undone within grace period
+	if err = finalizeRollOut(ctx, cluster, specList, state, isRolledOut); err != nil {
+		if !isRolledOut {
+			return false, interruptRollOut(ctx, cluster, specList, state, err)
+		}
+		if ctx.Err() != nil {
+			return false, errRollOutInterrupted
+		}
+		return false, err
+	}
+
+	return isRolledOut, nil
+}
+
+// Apply configuration in dry-run mode and report status of every resource, nothing is changed in cluster
+func runDryRun(ctx context.Context, cluster kubectl.Cluster, out io.Writer) error {
+	if imageRegistry != nil {
+		if err := checkImages(); err != nil {
+			return err
+		}
+	}
+	if imageRegistry != nil && pinDigests {
+		restore, err := pinImageDigests()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	fmt.Fprintf(out, "==> Applying configuration (dry-run: %s)...\n", dryRunMode)
+	output, err := cluster.ApplyDryRun(ctx, configurationYaml, dryRunMode)
+	if err != nil {
+		return err
+	}
+
+	// result is validated the same way as configuration
+	resultList, err := kubectl.ParseResources(output)
+	if err != nil {
+		return err
+	}
+	if len(resultList) == 0 {
+		return errors.New("dry-run returned no resources")
+	}
+
+	for _, r := range resultList {
+		live, err := getLiveManifest(ctx, cluster, r)
+		if err != nil {
+			return err
+		}
+
+		status := "created"
+		if live != nil {
+			liveData, resultData, err := kubectl.PrepareDiff(live, r.Manifest)
+			if err != nil {
+				return err
+			}
+
+			status = "configured"
+			if string(liveData) == string(resultData) {
+				status = "unchanged"
+			}
+		}
+
+		fmt.Fprintf(out, "===> %s: %s - %s (dry-run)\n", r.Kind, r.GetKey(), status)
+	}
+
+	return nil
+}
+
+// Undo half rolled configuration if context is done, cluster is given grace period to roll back
+func interruptRollOut(ctx context.Context, cluster kubectl.Cluster, specList *[]kubectl.RolloutResourceInterface, state *rollOutState, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	fmt.Printf("==> Rollout interrupted, starting undo process, grace period: %v\n", gracePeriod)
+	graceCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	rolledList, err := getRolledList(graceCtx, cluster, specList, true)
+	if err == nil {
+		err = revertRollOut(graceCtx, cluster, rolledList, state)
+	}
+	if err != nil {
+		fmt.Printf("===> Undo failed: %s\n", err)
+	}
+
+	return errRollOutInterrupted
+}
+
+// cancel context on first SIGINT/SIGTERM, next signal terminates process immediately
+func handleSignals() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Printf("==> Received %s, aborting rollout...\n", sig)
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// command handler
+func applyCmdHandler(cmd *cobra.Command, args []string) error {
+	cleanup, err := loadConfiguration()
+	if err != nil {
 		return err
 	}
 
-	// finalize deploy
-	if err = finalizeRollOut(specList, isRolledOut); err != nil {
+	if registryURLFlag != "" {
+		hitmanClient = registry.New(registryURLFlag)
+		if hitmanClient.IsValidURL() == false {
+			cleanup()
+			return fmt.Errorf("Request to %s/v2/ failed, is URL pointed to Docker Registry?", registryURLFlag)
+		}
+		imageRegistry = hitmanClient
+		imageRegistryHost = registryHost(registryURLFlag)
+	}
+
+	ctx, stop := handleSignals()
+	if dryRunMode != "" {
+		defer cleanup()
+		defer stop()
+		return runDryRun(ctx, kubectl.NewCluster(), os.Stdout)
+	}
+
+	isRolledOut, err := runApply(ctx, kubectl.NewCluster())
+	stop()
+	cleanup()
+
+	if err == errRollOutInterrupted {
+		fmt.Println("==> Rollout interrupted!")
+		os.Exit(exitCodeInterrupted)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// load cluster state after configuration is applied
+func applyClusterState(filename string) func(c *kubectltest.FakeCluster, configurationYaml string) ([]byte, error) {
+	return func(c *kubectltest.FakeCluster, configurationYaml string) ([]byte, error) {
+		resources, err := kubectl.ParseLocalFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Resources = resources
+		return []byte("deployment.apps/backend configured"), nil
+	}
+}
+
+// create fake cluster with state loaded from file
+func newClusterFromFile(t *testing.T, filename string) *kubectltest.FakeCluster {
+	resources, err := kubectl.ParseLocalFile(filename)
+	assert.Nil(t, err)
+
+	return kubectltest.NewFakeCluster(resources...)
+}
+
+func setupApplyTest(timeout time.Duration) {
+	configurationYaml = "testdata/deployment.yml"
+	clusterTimeout = timeout
+	maxRestarts = 3
+	pollInterval = time.Millisecond
+	deleteNew = false
+	dryRunMode = ""
+}
+
+func TestRunApply_Success(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_ready.yml")
+	cluster.LogList["default/backend-2-abcde/backend"] = "started"
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.True(t, isRolledOut)
+	assert.Equal(t, []string{"testdata/deployment.yml"}, cluster.AppliedList)
+	assert.Equal(t, []string{"deployment/default", "pod/default"}, cluster.WatchedList)
+	assert.Len(t, cluster.UndoneList, 0)
+}
+
+func TestRunApply_ProgressDeadlineExceeded(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	cluster := newClusterFromFile(t, "testdata/cluster_initial.yml")
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_failed.yml")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Equal(t, []string{"deployment/default/backend@1"}, cluster.UndoneList)
+}
+
+func TestRunApply_TimeoutWithoutHistory(t *testing.T) {
+	setupApplyTest(10 * time.Millisecond)
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = func(c *kubectltest.FakeCluster, configurationYaml string) ([]byte, error) {
+		resources, err := kubectl.ParseLocalFile(configurationYaml)
+		c.Resources = resources
+		return []byte{}, err
+	}
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.UndoneList, 0)
+}
+
+func TestRunApply_DeletedDuringRollout(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	resources, err := kubectl.ParseLocalFile(configurationYaml)
+	assert.Nil(t, err)
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = func(c *kubectltest.FakeCluster, configurationYaml string) ([]byte, error) {
+		c.Resources = resources
+		return []byte{}, nil
+	}
+	cluster.WatchEventList = []kubectl.WatchEvent{{Type: kubectl.WatchDeleted, Resource: resources[0]}}
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.EqualError(t, err, "Deployment default/backend was deleted during rollout")
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.UndoneList, 0)
+}
+
+func TestCheckRollOut_DaemonSetOldPodFailing(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	cluster := newClusterFromFile(t, "testdata/cluster_daemonset.yml")
+	rolledList := make([]kubectl.RolloutResourceInterface, 0)
+	for _, r := range cluster.Resources {
+		if d, ok := r.(*kubectl.DaemonSet); ok {
+			rolledList = append(rolledList, d)
+		}
+	}
+
+	// crash looping pod of previous revision doesn't abort rollout fixing it
+	isRolledOut, isFailed, err := checkRollOut(context.Background(), cluster, &rolledList, &rolledList, true)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.False(t, isFailed)
+
+	// new pod is crash looping
+	for _, r := range cluster.Resources {
+		if p, ok := r.(*kubectl.Pod); ok && p.Metadata.Name == "agent-new" {
+			p.Status.ContainerStatuses[0].RestartCount = 12
+		}
+	}
+	isRolledOut, isFailed, err = checkRollOut(context.Background(), cluster, &rolledList, &rolledList, true)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.True(t, isFailed)
+}
+
+func TestRunApply_NoRolloutResources(t *testing.T) {
+	setupApplyTest(time.Second)
+	configurationYaml = "testdata/configmap.yml"
+
+	cluster := kubectltest.NewFakeCluster()
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.EqualError(t, err, "no Deployment, StatefulSet, DaemonSet or Job resources found in configuration")
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.AppliedList, 0)
+}
+
+func TestRunApply_Polling(t *testing.T) {
+	setupApplyTest(time.Second)
+	watchRollout = false
+	defer func() { watchRollout = true }()
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_ready.yml")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.True(t, isRolledOut)
+	assert.Len(t, cluster.WatchedList, 0)
+}
+
+func TestRunApply_WatchFallback(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	cluster := newClusterFromFile(t, "testdata/cluster_initial.yml")
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_failed.yml")
+	cluster.WatchErr = errors.New("watch is not supported")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Equal(t, []string{"deployment/default/backend@1"}, cluster.UndoneList)
+}
+
+func TestRunApply_Interrupted(t *testing.T) {
+	setupApplyTest(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// signal received right after configuration is applied
+	cluster := newClusterFromFile(t, "testdata/cluster_initial.yml")
+	cluster.ApplyFunc = func(c *kubectltest.FakeCluster, configurationYaml string) ([]byte, error) {
+		cancel()
+		return applyClusterState("testdata/cluster_failed.yml")(c, configurationYaml)
+	}
+
+	isRolledOut, err := runApply(ctx, cluster)
+	assert.Equal(t, errRollOutInterrupted, err)
+	assert.False(t, isRolledOut)
+	assert.Equal(t, []string{"deployment/default/backend@1"}, cluster.UndoneList)
+}
+
+func TestRunApply_InterruptedUndo(t *testing.T) {
+	setupApplyTest(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// signal received while failed rollout is undone, running kubectl is killed
+	cluster := newClusterFromFile(t, "testdata/cluster_initial.yml")
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_failed.yml")
+	cluster.UndoFunc = func(c *kubectltest.FakeCluster, namespace, kind, name string, toRevision int) ([]byte, error) {
+		if ctx.Err() == nil {
+			cancel()
+			return nil, context.Canceled
+		}
+		return []byte{}, nil
+	}
+
+	isRolledOut, err := runApply(ctx, cluster)
+	assert.Equal(t, errRollOutInterrupted, err)
+	assert.False(t, isRolledOut)
+	assert.Equal(t, []string{"deployment/default/backend@1", "deployment/default/backend@1"}, cluster.UndoneList)
+}
+
+func TestRunApply_RevisionNotChanged(t *testing.T) {
+	setupApplyTest(time.Second)
+
+	// configuration is the same as failed one, there is nothing to roll back
+	cluster := newClusterFromFile(t, "testdata/cluster_failed.yml")
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_failed.yml")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.UndoneList, 0)
+}
+
+func TestRunApply_DeleteNew(t *testing.T) {
+	setupApplyTest(time.Second)
+	deleteNew = true
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_failed.yml")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.UndoneList, 0)
+	assert.Equal(t, []string{"deployment/default/backend"}, cluster.DeletedList)
+}
+
+func TestRunApply_RestoreSnapshots(t *testing.T) {
+	setupApplyTest(time.Second)
+	configurationYaml = "testdata/deployment_with_config.yml"
+
+	// config map exists, service is created by rollout
+	cluster := newClusterFromFile(t, "testdata/cluster_initial.yml")
+	cluster.ManifestList["configmap/default/backend-config"] = "kind: ConfigMap\nmetadata:\n  name: backend-config\ndata:\n  APP_ENV: production\n"
+
+	restored := ""
+	cluster.ApplyFunc = func(c *kubectltest.FakeCluster, filename string) ([]byte, error) {
+		if filename == configurationYaml {
+			stdout, err := applyClusterState("testdata/cluster_failed.yml")(c, filename)
+			service, _ := kubectl.ParseLocalFileResources(filename)
+			c.Resources = append(c.Resources, &service[1])
+			return stdout, err
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		restored = string(data)
+		return []byte("configmap/backend-config configured"), err
+	}
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.AppliedList, 2)
+	assert.Equal(t, "---\nkind: ConfigMap\nmetadata:\n  name: backend-config\ndata:\n  APP_ENV: production\n", restored)
+	assert.Equal(t, []string{"service/default/backend"}, cluster.DeletedList)
+	assert.Equal(t, []string{"deployment/default/backend@1"}, cluster.UndoneList)
+}
+
+func TestRunDryRun(t *testing.T) {
+	setupApplyTest(time.Second)
+	configurationYaml = "testdata/deployment_with_config.yml"
+	dryRunMode = kubectl.DryRunServer
+
+	cluster := kubectltest.NewFakeCluster()
+	data, err := ioutil.ReadFile(configurationYaml)
+	assert.Nil(t, err)
+	resourceList, err := kubectl.ParseResources(data)
+	assert.Nil(t, err)
+
+	// config map is not changed, service differs, deployment is new
+	cluster.ManifestList["configmap/default/backend-config"] = string(resourceList[0].Manifest)
+	cluster.ManifestList["service/default/backend"] = "kind: Service\nmetadata:\n  name: backend\nspec:\n  ports: []\n"
+
+	out := &bytes.Buffer{}
+	err = runDryRun(context.Background(), cluster, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "===> ConfigMap: default/backend-config - unchanged (dry-run)\n")
+	assert.Contains(t, out.String(), "===> Service: default/backend - configured (dry-run)\n")
+	assert.Contains(t, out.String(), "===> Deployment: default/backend - created (dry-run)\n")
+	assert.Equal(t, []string{"server: testdata/deployment_with_config.yml"}, cluster.DryRunList)
+	assert.Len(t, cluster.AppliedList, 0)
+	assert.Len(t, cluster.WatchedList, 0)
+}
+
+func TestRunDryRun_RemovedField(t *testing.T) {
+	setupApplyTest(time.Second)
+	configurationYaml = "testdata/configmap.yml"
+	dryRunMode = kubectl.DryRunClient
+
+	// key removed from configuration is still recorded in last applied configuration
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ManifestList["configmap/default/backend-config"] = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backend-config
+  namespace: default
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"backend-config","namespace":"default"},"data":{"APP_DEBUG":"1","APP_ENV":"production"}}'
+data:
+  APP_DEBUG: "1"
+  APP_ENV: production
+`
+
+	out := &bytes.Buffer{}
+	err := runDryRun(context.Background(), cluster, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "===> ConfigMap: default/backend-config - configured (dry-run)\n")
+}
+
+func TestRunDryRun_Empty(t *testing.T) {
+	setupApplyTest(time.Second)
+	dryRunMode = kubectl.DryRunClient
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.DryRunFunc = func(c *kubectltest.FakeCluster, configurationYaml, mode string) ([]byte, error) {
+		return []byte("apiVersion: v1\nkind: List\nitems: []\n"), nil
+	}
+
+	err := runDryRun(context.Background(), cluster, ioutil.Discard)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/render"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	configurationSourceList []string
+	recursiveFlag           bool
+	templateFlag            bool
+	valuesFileList          []string
+	setValueList            []string
+	patchFileList           []string
+	patchType               string
+)
+
+// register configuration source flags, shared by commands working with configuration
+func addConfigurationFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringSliceVarP(&configurationSourceList, "configuration", "f", nil, usage)
+	cmd.MarkFlagRequired("configuration")
+	cmd.MarkFlagFilename("configuration", "yml", "yaml", "json")
+
+	cmd.Flags().BoolVarP(&recursiveFlag, "recursive", "R", false, "Process configuration directories recursively")
+	cmd.Flags().BoolVar(&templateFlag, "template", false, "Render configuration as Go template, implied by --set and --values")
+	cmd.Flags().StringSliceVar(&valuesFileList, "values", nil, "Template values file (yaml), can be repeated, later files override earlier ones")
+	cmd.Flags().StringArrayVar(&setValueList, "set", nil, "Template value \"key.path=value\", can be repeated, overrides values files")
+	cmd.Flags().StringSliceVar(&patchFileList, "patch", nil, "Patch files or directories applied to configuration resources with the same kind, namespace and name")
+	cmd.Flags().StringVar(&patchType, "patch-type", kubectl.PatchTypeStrategic, "Patch type, \"strategic\" or \"merge\"")
+}
+
+// Merge sources and render them as template if requested,
+// the same decision is made for every command, so render prints exactly what apply uses
+func readSources(name string, sourceList []string) ([]byte, error) {
+	data, err := kubectl.ReadConfiguration(sourceList, recursiveFlag, os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !templateFlag && len(valuesFileList) == 0 && len(setValueList) == 0 {
+		return data, nil
+	}
+
+	values, err := render.NewValues(valuesFileList, setValueList)
+	if err != nil {
+		return nil, err
+	}
+
+	return render.Render(name, data, values)
+}
+
+// Merge all configuration sources, render them and apply patches
+func readConfiguration() ([]byte, error) {
+	data, err := readSources("configuration", configurationSourceList)
+	if err != nil || len(patchFileList) == 0 {
+		return data, err
+	}
+
+	patchData, err := readSources("patch", patchFileList)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceList, err := kubectl.ParseResources(data)
+	if err != nil {
+		return nil, err
+	}
+	patchList, err := kubectl.ParseResources(patchData)
+	if err != nil {
+		return nil, err
+	}
+
+	patchedList, err := kubectl.ApplyPatches(resourceList, patchList, patchType)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubectl.JoinResources(patchedList), nil
+}
+
+// write configuration to temporary file
+func writeTempConfiguration(data []byte) (string, error) {
+	file, err := ioutil.TempFile("", "fuse-configuration")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// Merge all configuration sources into single temporary file, so parser and kubectl
+// see exactly the same set of resources, returned function removes the file
+func loadConfiguration() (func(), error) {
+	data, err := readConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err := writeTempConfiguration(data)
+	if err != nil {
+		return nil, err
+	}
+
+	configurationYaml = filename
+	return func() {
+		os.Remove(filename)
+	}, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLoadConfiguration(t *testing.T) {
+	configurationSourceList = []string{"testdata/deployment.yml", "testdata/deployment_with_config.yml"}
+	recursiveFlag = false
+	defer func() { configurationSourceList = nil }()
+
+	cleanup, err := loadConfiguration()
+	assert.Nil(t, err)
+
+	// rollout resources and applied file are the same merged set
+	specList, err := initRollOut()
+	assert.Nil(t, err)
+	assert.Len(t, *specList, 2)
+
+	resourceList, err := kubectl.ParseLocalFileResources(configurationYaml)
+	assert.Nil(t, err)
+	assert.Len(t, resourceList, 4)
+
+	cleanup()
+	_, err = os.Stat(configurationYaml)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoadConfiguration_Missing(t *testing.T) {
+	configurationSourceList = []string{"testdata/missing.yml"}
+	defer func() { configurationSourceList = nil }()
+
+	_, err := loadConfiguration()
+	assert.NotNil(t, err)
+}
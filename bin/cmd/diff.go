@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Dalee/fuse/pkg/diff"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// exit code of diff command, when configuration differs from cluster state
+	exitCodeChanged = 2
+
+	// ANSI colors used in diff output
+	colorReset = "\x1b[0m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff",
+		Short: "Show changes apply would make",
+		Long:  `Compare configuration with live state of every resource in Kubernetes cluster`,
+		RunE:  diffCmdHandler,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(configurationSourceList) == 0 {
+				return errors.New("mandatory configuration spec filename is not provided")
+			}
+			return nil
+		},
+	}
+
+	diffColor bool
+)
+
+func init() {
+	addConfigurationFlags(diffCmd, "Configuration spec files, directories or \"-\" for stdin (yaml), mandatory")
+
+	diffCmd.Flags().BoolVar(&diffColor, "color", true, "Colorize diff output")
+	RootCmd.AddCommand(diffCmd)
+}
+
+// colorize unified diff lines
+func colorizeDiff(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = colorCyan + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		case strings.HasPrefix(line, "-"):
+			lines[i] = colorRed + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		case strings.HasPrefix(line, "+"):
+			lines[i] = colorGreen + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+// print diff of every resource defined in configuration, returns whether any resource differs
+func runDiff(ctx context.Context, cluster kubectl.Cluster, out io.Writer) (bool, error) {
+	resourceList, err := kubectl.ParseLocalFileResources(configurationYaml)
+	if err != nil {
+		return false, err
+	}
+
+	changedCount := 0
+	for _, r := range resourceList {
+		live, err := getLiveManifest(ctx, cluster, r)
+		if err != nil {
+			return false, err
+		}
+
+		liveData, localData, err := kubectl.PrepareDiff(live, r.Manifest)
+		if err != nil {
+			return false, err
+		}
+
+		name := fmt.Sprintf("%s/%s", r.GetKind(), r.GetKey())
+		text := diff.Unified("live/"+name, "local/"+name, string(liveData), string(localData), 3)
+		if text == "" {
+			continue
+		}
+
+		changedCount++
+		if diffColor {
+			text = colorizeDiff(text)
+		}
+		fmt.Fprint(out, text)
+	}
+
+	fmt.Fprintf(out, "==> %d of %d resources changed\n", changedCount, len(resourceList))
+	return changedCount > 0, nil
+}
+
+// command handler
+func diffCmdHandler(cmd *cobra.Command, args []string) error {
+	cleanup, err := loadConfiguration()
+	if err != nil {
+		return err
+	}
+
+	isChanged, err := runDiff(context.Background(), kubectl.NewCluster(), os.Stdout)
+	cleanup()
+	if err != nil {
+		return err
+	}
+
+	// signalize to CI/CD about changes
+	if isChanged {
+		os.Exit(exitCodeChanged)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRunDiff(t *testing.T) {
+	configurationYaml = "testdata/deployment_with_config.yml"
+	diffColor = false
+
+	// config map differs, service is new, deployment has defaulted fields only
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ManifestList["configmap/default/backend-config"] = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backend-config
+  namespace: default
+  resourceVersion: "42"
+data:
+  APP_ENV: production
+`
+	cluster.ManifestList["deployment/default/backend"] = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  namespace: default
+  generation: 4
+spec:
+  progressDeadlineSeconds: 600
+  replicas: 1
+  selector:
+    matchLabels:
+      app: backend
+  template:
+    metadata:
+      labels:
+        app: backend
+    spec:
+      containers:
+      - name: backend
+        image: registry.example.com/backend:v2
+        imagePullPolicy: IfNotPresent
+        envFrom:
+        - configMapRef:
+            name: backend-config
+status:
+  replicas: 1
+`
+
+	out := &bytes.Buffer{}
+	isChanged, err := runDiff(context.Background(), cluster, out)
+	assert.Nil(t, err)
+	assert.True(t, isChanged)
+	assert.Equal(t, `--- live/configmap/default/backend-config
++++ local/configmap/default/backend-config
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  APP_ENV: production
++  APP_ENV: staging
+ kind: ConfigMap
+ metadata:
+   name: backend-config
+--- live/service/default/backend
++++ local/service/default/backend
+@@ -0,0 +1,10 @@
++apiVersion: v1
++kind: Service
++metadata:
++  name: backend
++  namespace: default
++spec:
++  ports:
++  - port: 80
++  selector:
++    app: backend
+==> 2 of 3 resources changed
+`, out.String())
+}
+
+func TestRunDiff_NotChanged(t *testing.T) {
+	configurationYaml = "testdata/deployment.yml"
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ManifestList["deployment/default/backend"] = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: backend
+  template:
+    metadata:
+      labels:
+        app: backend
+    spec:
+      containers:
+      - name: backend
+        image: registry.example.com/backend:v2
+`
+
+	out := &bytes.Buffer{}
+	isChanged, err := runDiff(context.Background(), cluster, out)
+	assert.Nil(t, err)
+	assert.False(t, isChanged)
+	assert.Equal(t, "==> 0 of 1 resources changed\n", out.String())
+}
+
+func TestColorizeDiff(t *testing.T) {
+	assert.Equal(t, "--- a\n+++ b\n\x1b[36m@@ -1 +1 @@\x1b[0m\n\x1b[31m-x\x1b[0m\n\x1b[32m+y\x1b[0m\n z\n", colorizeDiff("--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n z\n"))
+}
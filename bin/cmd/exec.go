@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/Dalee/fuse/pkg/kubectl"
@@ -30,6 +31,11 @@ func init() {
 
 // command handler
 func execCmdHandler(cmd *cobra.Command, args []string) error {
+	return runExec(context.Background(), kubectl.NewCluster())
+}
+
+// execute command in every container of every pod of selected deployments
+func runExec(ctx context.Context, cluster kubectl.Cluster) error {
 	if execCommand == "" {
 		return errors.New("No command provided")
 	}
@@ -42,12 +48,11 @@ func execCmdHandler(cmd *cobra.Command, args []string) error {
 	// get deployment list by selector
 	deploymentList := make([]kubectl.Deployment, 0)
 	for _, s := range deploymentSelectors {
-		resourceList, err := kubectl.CommandDeploymentListBySelector(namespaceFlag, []string{s}).RunAndParse()
+		dl, err := cluster.ListDeployments(ctx, namespaceFlag, []string{s})
 		if err != nil {
 			return err
 		}
 
-		dl := resourceList.ToDeploymentList()
 		deploymentList = append(deploymentList, dl...)
 	}
 
@@ -55,12 +60,11 @@ func execCmdHandler(cmd *cobra.Command, args []string) error {
 
 	// for each deployment, find all pods
 	for _, d := range deploymentList {
-		podResourceList, err := kubectl.CommandPodListBySelector(namespaceFlag, d.GetPodSelector()).RunAndParse()
+		pl, err := cluster.ListPods(ctx, namespaceFlag, d.GetPodSelector())
 		if err != nil {
 			return err
 		}
 
-		pl := podResourceList.ToPodList()
 		podList = append(podList, pl...)
 	}
 
@@ -69,7 +73,7 @@ func execCmdHandler(cmd *cobra.Command, args []string) error {
 		for _, c := range pod.Spec.Containers {
 			podName := pod.GetName()
 
-			stdout, err := kubectl.CommandExec(namespaceFlag, podName, c.Name, execCommand).RunPlain()
+			stdout, err := cluster.Exec(ctx, namespaceFlag, podName, c.Name, execCommand)
 			fmt.Printf("===> Pod: %s, Container: %s:\n", pod.GetKey(), c.Name)
 			fmt.Println(string(stdout))
 
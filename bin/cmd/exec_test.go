@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRunExec(t *testing.T) {
+	resources, err := kubectl.ParseLocalFile("testdata/cluster_ready.yml")
+	assert.Nil(t, err)
+
+	namespaceFlag = "default"
+	deploymentSelectors = []string{"app=backend"}
+	execCommand = "php artisan migrate"
+
+	cluster := kubectltest.NewFakeCluster(resources...)
+	err = runExec(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default/backend-2-abcde/backend: php artisan migrate"}, cluster.ExecutedList)
+}
+
+func TestRunExec_NoCommand(t *testing.T) {
+	execCommand = ""
+
+	cluster := kubectltest.NewFakeCluster()
+	err := runExec(context.Background(), cluster)
+	assert.NotNil(t, err)
+	assert.Len(t, cluster.ExecutedList, 0)
+}
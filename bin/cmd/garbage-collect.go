@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/Dalee/fuse/pkg/distribution"
 	"github.com/Dalee/fuse/pkg/kubectl"
 	"github.com/Dalee/fuse/pkg/reference"
 
@@ -12,11 +15,19 @@ import (
 	"time"
 )
 
+type (
+	// Hitman client extended with Docker Distribution calls Hitman doesn't provide
+	garbageRegistry struct {
+		*registry.Registry
+		*distribution.Client
+	}
+)
+
 var (
 	// command itself
 	garbageCollectCmd = &cobra.Command{
 		Use:   "garbage-collect",
-		Short: "Remove tags from registry not registered within Kubernetes ReplicaSet",
+		Short: "Remove tags from registry not used by any Kubernetes workload",
 		Long:  ``,
 		RunE:  garbageCollectCmdHandler,
 	}
@@ -26,9 +37,21 @@ var (
 	ignoreMissingFlag = false
 	registryURLFlag   = ""
 	ignoreTags        = make([]string, 0)
+	keepTagRegexList  = make([]string, 0)
+	keepLastFlag      = 0
+	keepYoungerThan   time.Duration
+	policyFile        = ""
+	allNamespacesFlag = false
+	gcContextList     = make([]string, 0)
+	scanCatalogFlag   = false
+	deleteOrphansFlag = false
 
 	// Docker Distribution client
 	hitmanClient *registry.Registry
+	garbageAPI   *garbageRegistry
+
+	// retention policy built from flags and policy file
+	retentionPolicy reference.PolicyInterface
 )
 
 // register all flags
@@ -37,29 +60,83 @@ func init() {
 	garbageCollectCmd.Flags().StringVarP(&registryURLFlag, "registry-url", "r", "", "Registry URL (e.g. \"https://registry.example.com:5000/\")")
 	garbageCollectCmd.Flags().BoolVarP(&ignoreMissingFlag, "ignore-missing", "i", false, "Skip missing images in Registry (default \"false\")")
 	garbageCollectCmd.Flags().StringSliceVarP(&ignoreTags, "keep-tag", "k", []string{}, "Keep tag in Registry, even if it not deployed (default none)")
+	garbageCollectCmd.Flags().StringArrayVar(&keepTagRegexList, "keep-tag-regex", []string{}, "Keep tags matching regular expression in Registry, can be repeated (default none)")
+	garbageCollectCmd.Flags().IntVar(&keepLastFlag, "keep-last", 0, "Keep number of most recent digests of every repository in Registry, deployed ones included (default none)")
+	garbageCollectCmd.Flags().DurationVar(&keepYoungerThan, "keep-younger-than", 0, "Keep digests pushed within duration in Registry, e.g. \"72h\" (default none)")
+	garbageCollectCmd.Flags().StringVar(&policyFile, "policy", "", "Retention policy file (yaml), \"*\" of pattern matches single path segment, \"**\" any number of them, repositories not matching any pattern are handled by keep flags")
+	garbageCollectCmd.MarkFlagFilename("policy", "yml", "yaml")
+	garbageCollectCmd.Flags().StringVarP(&namespaceFlag, "namespace", "n", "default", "Kubernetes namespace to use")
+	garbageCollectCmd.Flags().BoolVar(&allNamespacesFlag, "all-namespaces", false, "Collect deployed images from every namespace (default \"false\")")
+	garbageCollectCmd.Flags().StringSliceVar(&gcContextList, "collect-context", []string{}, "Collect deployed images from cluster context, can be repeated (default --context)")
+	garbageCollectCmd.Flags().BoolVar(&scanCatalogFlag, "scan-catalog", false, "Report repositories of Registry catalog without deployed images (default \"false\")")
+	garbageCollectCmd.Flags().BoolVar(&deleteOrphansFlag, "delete-orphans", false, "Confirm deletion of repositories found by --scan-catalog, requires --all-namespaces and --collect-context, clusters not listed are treated as having no deployed images (default \"false\")")
 	RootCmd.AddCommand(garbageCollectCmd)
 }
 
-// get garbage from docker distribution, list of repositories from kubernetes replica sets
-func getGarbage() (*reference.GarbageDetectInfo, error) {
-	fmt.Println("==> Fetching repository info...")
-	resourceList, err := kubectl.CommandReplicaSetList(namespaceFlag).RunAndParse()
+// build retention policy from keep flags, policy file overrides it for matching repositories
+func getRetentionPolicy() (reference.PolicyInterface, error) {
+	defaultPolicy, err := reference.NewRetentionPolicy(ignoreTags, keepTagRegexList, keepLastFlag, keepYoungerThan)
 	if err != nil {
 		return nil, err
 	}
 
-	// collect all images defined in every ReplicaSet
-	cnList := make([]string, 0)
-	rsList := resourceList.ToReplicaSetList()
+	if policyFile == "" {
+		return defaultPolicy, nil
+	}
+
+	return reference.LoadPolicySet(policyFile, defaultPolicy)
+}
 
-	for _, rs := range rsList {
-		for _, image := range rs.GetImages() {
-			cnList = append(cnList, image)
+// collect images in use by every workload of every requested cluster context,
+// failure of any context is an error, partial list could mark deployed images as garbage
+func getDeployedImages(ctx context.Context, newCluster func(contextName string) kubectl.Cluster) ([]string, error) {
+	contextList := gcContextList
+	if len(contextList) == 0 {
+		contextList = []string{""}
+	}
+
+	namespace := namespaceFlag
+	if allNamespacesFlag {
+		namespace = kubectl.AllNamespaces
+	}
+
+	cnList := make([]string, 0)
+	for _, contextName := range contextList {
+		imageList, err := kubectl.CollectImages(ctx, newCluster(contextName), namespace)
+		if err != nil {
+			if contextName == "" {
+				return nil, fmt.Errorf("Unable to collect deployed images: %s", err)
+			}
+			return nil, fmt.Errorf("Unable to collect deployed images of context %s: %s", contextName, err)
 		}
+
+		cnList = append(cnList, imageList...)
+	}
+
+	return cnList, nil
+}
+
+// orphaned repositories are deleted only on explicit request and only when every namespace is collected,
+// otherwise repositories deployed to other namespaces are deleted too
+func validateCatalogFlags() error {
+	if !deleteOrphansFlag {
+		return nil
+	}
+	if !scanCatalogFlag {
+		return errors.New("--delete-orphans requires --scan-catalog")
+	}
+	if !allNamespacesFlag {
+		return errors.New("--delete-orphans requires --all-namespaces, repositories deployed to other namespaces would be deleted")
 	}
+	if len(gcContextList) == 0 {
+		return errors.New("--delete-orphans requires --collect-context of every cluster using registry, repositories deployed to other clusters would be deleted")
+	}
+	return nil
+}
 
-	// perform detection
-	garbageInfo, err := reference.DetectGarbage(cnList, ignoreTags, hitmanClient, ignoreMissingFlag)
+// get garbage from docker distribution, list of repositories from kubernetes workloads
+func getGarbage(cnList []string) (*reference.GarbageDetectInfo, error) {
+	garbageInfo, err := reference.DetectGarbage(cnList, retentionPolicy, garbageAPI, ignoreMissingFlag)
 	if err != nil {
 		return nil, err
 	}
@@ -67,12 +144,44 @@ func getGarbage() (*reference.GarbageDetectInfo, error) {
 	return garbageInfo, nil
 }
 
+// get repositories of registry catalog without deployed images
+func getOrphans(cnList []string) (*reference.GarbageDetectInfo, error) {
+	fmt.Println("==> Fetching registry catalog...")
+	catalog, err := garbageAPI.GetCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	return reference.DetectOrphans(cnList, catalog, retentionPolicy, garbageAPI, ignoreMissingFlag)
+}
+
 // printing report
 func printGarbage(garbageInfo *reference.GarbageDetectInfo) error {
 	fmt.Printf("==> Found %d repositories\n", len(garbageInfo.Items))
 	for _, item := range garbageInfo.Items {
 		fmt.Printf("===> Repository: %s\n", item.Repository)
+		fmt.Printf("=====> Policy: %s\n", item.Policy)
 		fmt.Printf("=====> Deployed: %v\n", item.DeployedTagList)
+		if len(item.DeployedDigestList) > 0 {
+			fmt.Printf("=====> Deployed by digest: %v\n", item.DeployedDigestList)
+		}
+		if len(item.RetainedDigestList) > 0 {
+			fmt.Printf("=====> Retained by policy: %v\n", item.RetainedTagList)
+		}
+		fmt.Printf("=====> Detected as garbage: %v\n\n", item.GarbageTagList)
+	}
+	return nil
+}
+
+// printing report of orphaned repositories
+func printOrphans(orphanInfo *reference.GarbageDetectInfo) error {
+	fmt.Printf("==> Found %d orphaned repositories\n", len(orphanInfo.Items))
+	for _, item := range orphanInfo.Items {
+		fmt.Printf("===> Repository: %s\n", item.Repository)
+		fmt.Printf("=====> Policy: %s\n", item.Policy)
+		if len(item.RetainedDigestList) > 0 {
+			fmt.Printf("=====> Retained by policy: %v\n", item.RetainedTagList)
+		}
 		fmt.Printf("=====> Detected as garbage: %v\n\n", item.GarbageTagList)
 	}
 	return nil
@@ -110,13 +219,36 @@ func garbageCollectCmdHandler(cmd *cobra.Command, args []string) error {
 		return errors.New("registry-url is a mandatory parameter")
 	}
 
+	if err = validateCatalogFlags(); err != nil {
+		return err
+	}
+
+	retentionPolicy, err = getRetentionPolicy()
+	if err != nil {
+		return err
+	}
+
 	hitmanClient = registry.New(registryURLFlag)
 	if hitmanClient.IsValidURL() == false {
 		return fmt.Errorf("Request to %s/v2/ failed, is URL pointed to Docker Registry?", registryURLFlag)
 	}
+	garbageAPI = &garbageRegistry{Registry: hitmanClient, Client: distribution.New(registryURLFlag)}
+
+	// collect deployed images
+	fmt.Println("==> Fetching repository info...")
+	cnList, err := getDeployedImages(context.Background(), kubectl.NewClusterForContext)
+	if err != nil {
+		return err
+	}
+
+	// repositories of other registries may have the same path, they must not keep garbage of this one
+	cnList, err = reference.FilterRegistryImages(cnList, registryHost(registryURLFlag))
+	if err != nil {
+		return err
+	}
 
 	// detect garbage
-	garbageInfo, err := getGarbage()
+	garbageInfo, err := getGarbage(cnList)
 	if err != nil {
 		return err
 	}
@@ -127,12 +259,37 @@ func garbageCollectCmdHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// detect orphaned repositories
+	var orphanInfo *reference.GarbageDetectInfo
+	if scanCatalogFlag {
+		orphanInfo, err = getOrphans(cnList)
+		if err != nil {
+			return err
+		}
+
+		err = printOrphans(orphanInfo)
+		if err != nil {
+			return err
+		}
+	}
+
 	// clearing up if not dry-run
 	if dryRunFlag == false {
 		err = deleteGarbage(garbageInfo)
 		if err != nil {
 			return err
 		}
+
+		if orphanInfo != nil && deleteOrphansFlag == false {
+			fmt.Println("==> Orphaned repositories are kept, use --delete-orphans to delete them")
+		}
+		if orphanInfo != nil && deleteOrphansFlag {
+			fmt.Printf("==> Deleting orphaned repositories, clusters other than %s are treated as having no deployed images\n", strings.Join(gcContextList, ", "))
+			err = deleteGarbage(orphanInfo)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
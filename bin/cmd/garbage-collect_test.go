@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func setupGarbageCollectTest(namespace string, allNamespaces bool, contextList ...string) {
+	namespaceFlag = namespace
+	allNamespacesFlag = allNamespaces
+	gcContextList = contextList
+}
+
+// cluster factory returning prepared cluster for every context
+func fakeContextClusters(clusterList map[string]*kubectltest.FakeCluster) func(contextName string) kubectl.Cluster {
+	return func(contextName string) kubectl.Cluster {
+		return clusterList[contextName]
+	}
+}
+
+func TestGetDeployedImages_Namespace(t *testing.T) {
+	setupGarbageCollectTest("default", false)
+
+	newCluster := fakeContextClusters(map[string]*kubectltest.FakeCluster{
+		"": newClusterFromFile(t, "testdata/cluster_staging.yml"),
+	})
+
+	imageList, err := getDeployedImages(context.Background(), newCluster)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"registry.example.com/frontend:v1"}, imageList)
+}
+
+func TestGetDeployedImages_AllNamespacesAndContexts(t *testing.T) {
+	setupGarbageCollectTest("default", true, "production", "staging")
+
+	newCluster := fakeContextClusters(map[string]*kubectltest.FakeCluster{
+		"production": newClusterFromFile(t, "testdata/cluster_failed.yml"),
+		"staging":    newClusterFromFile(t, "testdata/cluster_staging.yml"),
+	})
+
+	imageList, err := getDeployedImages(context.Background(), newCluster)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"registry.example.com/backend:v1",
+		"registry.example.com/backend:v2",
+		"registry.example.com/backend:v3",
+		"registry.example.com/frontend:v1",
+	}, imageList)
+}
+
+func TestGetDeployedImages_ContextFailed(t *testing.T) {
+	setupGarbageCollectTest("default", true, "production", "staging")
+
+	staging := newClusterFromFile(t, "testdata/cluster_staging.yml")
+	staging.ListErr = errors.New("connection refused")
+
+	newCluster := fakeContextClusters(map[string]*kubectltest.FakeCluster{
+		"production": newClusterFromFile(t, "testdata/cluster_failed.yml"),
+		"staging":    staging,
+	})
+
+	imageList, err := getDeployedImages(context.Background(), newCluster)
+	assert.Nil(t, imageList)
+	assert.EqualError(t, err, "Unable to collect deployed images of context staging: unable to list pod: connection refused")
+}
+
+func TestGetRetentionPolicy(t *testing.T) {
+	ignoreTags = []string{"latest"}
+	keepTagRegexList = []string{"^release-"}
+	keepLastFlag = 5
+	keepYoungerThan = 0
+
+	policyFile = ""
+	policy, err := getRetentionPolicy()
+	assert.Nil(t, err)
+	assert.Equal(t, "default, keep-last=5, keep-younger-than=0s, keep-tag=[latest], keep-tag-regex=[^release-]", policy.ForRepository("base/alpine").String())
+
+	policyFile = "testdata/policy.yml"
+	policy, err = getRetentionPolicy()
+	assert.Nil(t, err)
+	assert.Equal(t, `pattern "base/*", protected`, policy.ForRepository("base/alpine").String())
+	assert.Equal(t, 5, policy.ForRepository("backend").KeepLast)
+
+	keepLastFlag = -1
+	_, err = getRetentionPolicy()
+	assert.EqualError(t, err, "Invalid number of kept digests: -1")
+
+	policyFile = ""
+	keepLastFlag = 0
+	ignoreTags = []string{}
+	keepTagRegexList = []string{}
+}
+
+func TestValidateCatalogFlags(t *testing.T) {
+	scanCatalogFlag, deleteOrphansFlag, allNamespacesFlag = true, false, false
+	assert.Nil(t, validateCatalogFlags())
+
+	scanCatalogFlag, deleteOrphansFlag, allNamespacesFlag = false, true, true
+	assert.EqualError(t, validateCatalogFlags(), "--delete-orphans requires --scan-catalog")
+
+	scanCatalogFlag, deleteOrphansFlag, allNamespacesFlag = true, true, false
+	assert.EqualError(t, validateCatalogFlags(), "--delete-orphans requires --all-namespaces, repositories deployed to other namespaces would be deleted")
+
+	// implicit current context only
+	scanCatalogFlag, deleteOrphansFlag, allNamespacesFlag = true, true, true
+	gcContextList = []string{}
+	assert.EqualError(t, validateCatalogFlags(), "--delete-orphans requires --collect-context of every cluster using registry, repositories deployed to other clusters would be deleted")
+
+	gcContextList = []string{"staging", "production"}
+	assert.Nil(t, validateCatalogFlags())
+
+	scanCatalogFlag, deleteOrphansFlag, allNamespacesFlag = false, false, false
+	gcContextList = []string{}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/reference"
+	"github.com/Dalee/hitman/pkg/registry"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type (
+	// interface to hitman/pkg/registry, used by apply image checks
+	imageRegistryInterface interface {
+		GetImageDigestList(repo string) (*registry.RepositoryDigestList, error)
+	}
+)
+
+var (
+	// registry images of configuration are checked against, disabled if nil
+	imageRegistry     imageRegistryInterface
+	imageRegistryHost string
+)
+
+// registry host as used in image references, "https://registry.example.com:5000/" -> "registry.example.com:5000"
+func registryHost(registryURL string) string {
+	u, err := url.Parse(registryURL)
+	if err != nil || u.Host == "" {
+		return strings.Trim(registryURL, "/")
+	}
+	return u.Host
+}
+
+// collect images of every pod template defined in configuration
+func getConfigurationImages() ([]string, error) {
+	resourceList, err := kubectl.ParseLocalFileResources(configurationYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	imageList := make([]string, 0)
+	for _, r := range resourceList {
+		images, err := r.GetImages()
+		if err != nil {
+			return nil, err
+		}
+		imageList = append(imageList, images...)
+	}
+
+	return imageList, nil
+}
+
+// Make sure every image of configuration is pushed to registry, nothing is applied otherwise
+func checkImages() error {
+	fmt.Printf("==> Checking images in %s...\n", imageRegistryHost)
+	imageList, err := getConfigurationImages()
+	if err != nil {
+		return err
+	}
+
+	checkInfo, err := reference.CheckImages(imageList, imageRegistryHost, imageRegistry)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range checkInfo.CheckedList {
+		fmt.Printf("===> %s - found\n", image)
+	}
+	for _, image := range checkInfo.SkippedList {
+		fmt.Printf("===> %s - skipped, hosted by other registry\n", image)
+	}
+	for _, image := range checkInfo.MissingList {
+		fmt.Printf("===> %s - missing\n", image)
+	}
+
+	if len(checkInfo.MissingList) > 0 {
+		return fmt.Errorf("%d images missing in registry: %s", len(checkInfo.MissingList), strings.Join(checkInfo.MissingList, ", "))
+	}
+
+	return nil
+}
+
+// Resolve image tags to digests and switch to configuration with pinned images,
+// returned function removes pinned configuration and restores original one
+func pinImageDigests() (func(), error) {
+	fmt.Println("==> Pinning images to digests...")
+	imageList, err := getConfigurationImages()
+	if err != nil {
+		return nil, err
+	}
+
+	pinnedList, err := reference.ResolveDigests(imageList, imageRegistryHost, imageRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	reference.RemoveDuplicates(&imageList)
+	for _, image := range imageList {
+		if pinned, ok := pinnedList[image]; ok {
+			fmt.Printf("===> %s -> %s\n", image, pinned)
+		} else {
+			fmt.Printf("===> %s - not pinned\n", image)
+		}
+	}
+
+	resourceList, err := kubectl.ParseLocalFileResources(configurationYaml)
+	if err != nil {
+		return nil, err
+	}
+	resourceList, err = kubectl.ReplaceImages(resourceList, pinnedList)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err := writeTempConfiguration(kubectl.JoinResources(resourceList))
+	if err != nil {
+		return nil, err
+	}
+
+	original := configurationYaml
+	configurationYaml = filename
+	return func() {
+		os.Remove(filename)
+		configurationYaml = original
+	}, nil
+}
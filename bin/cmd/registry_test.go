@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/Dalee/hitman/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type (
+	// in-memory registry, keyed by repository
+	fakeImageRegistry map[string]*registry.RepositoryDigestList
+)
+
+func (r fakeImageRegistry) GetImageDigestList(repo string) (*registry.RepositoryDigestList, error) {
+	digestList, ok := r[repo]
+	if !ok {
+		return nil, errors.New("repository not found")
+	}
+	return digestList, nil
+}
+
+func setupRegistryTest(tagList ...string) {
+	imageRegistryHost = "registry.example.com"
+	imageRegistry = fakeImageRegistry{
+		"backend": &registry.RepositoryDigestList{
+			Children: []*registry.RepositoryDigest{
+				{Name: "sha256:backend-digest", Path: "backend", TagList: tagList},
+			},
+		},
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	assert.Equal(t, "registry.example.com:5000", registryHost("https://registry.example.com:5000/"))
+	assert.Equal(t, "registry.example.com", registryHost("registry.example.com/"))
+}
+
+func TestRunApply_ImagesFound(t *testing.T) {
+	setupApplyTest(time.Second)
+	setupRegistryTest("v1", "v2")
+	defer func() { imageRegistry = nil }()
+
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = applyClusterState("testdata/cluster_ready.yml")
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.True(t, isRolledOut)
+}
+
+func TestRunApply_ImagesMissing(t *testing.T) {
+	setupApplyTest(time.Second)
+	setupRegistryTest("v1")
+	defer func() { imageRegistry = nil }()
+
+	cluster := kubectltest.NewFakeCluster()
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.EqualError(t, err, "1 images missing in registry: registry.example.com/backend:v2")
+	assert.False(t, isRolledOut)
+	assert.Len(t, cluster.AppliedList, 0)
+}
+
+func TestRunApply_PinDigests(t *testing.T) {
+	setupApplyTest(time.Second)
+	setupRegistryTest("v2")
+	pinDigests = true
+	defer func() {
+		imageRegistry = nil
+		pinDigests = false
+	}()
+
+	applied := ""
+	cluster := kubectltest.NewFakeCluster()
+	cluster.ApplyFunc = func(c *kubectltest.FakeCluster, filename string) ([]byte, error) {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		applied = string(data)
+		return applyClusterState("testdata/cluster_ready.yml")(c, filename)
+	}
+
+	isRolledOut, err := runApply(context.Background(), cluster)
+	assert.Nil(t, err)
+	assert.True(t, isRolledOut)
+	assert.Contains(t, applied, "image: registry.example.com/backend:v2@sha256:backend-digest\n")
+
+	// pinned configuration is removed, original one is restored
+	assert.Equal(t, "testdata/deployment.yml", configurationYaml)
+	_, err = os.Stat(cluster.AppliedList[0])
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+var (
+	renderCmd = &cobra.Command{
+		Use:   "render",
+		Short: "Print rendered configuration",
+		Long:  `Render configuration templates with values and environment, and print configuration apply would use with the same flags`,
+		RunE:  renderCmdHandler,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(configurationSourceList) == 0 {
+				return errors.New("mandatory configuration spec filename is not provided")
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	addConfigurationFlags(renderCmd, "Configuration spec files, directories or \"-\" for stdin (yaml), mandatory")
+	RootCmd.AddCommand(renderCmd)
+}
+
+// render configuration and print it, result must be valid configuration
+func runRender(out io.Writer) error {
+	data, err := readConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if _, err := kubectl.ParseResources(data); err != nil {
+		return err
+	}
+
+	_, err = out.Write(data)
+	return err
+}
+
+// command handler
+func renderCmdHandler(cmd *cobra.Command, args []string) error {
+	return runRender(os.Stdout)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func setupRenderTest(setList ...string) {
+	configurationSourceList = []string{"testdata/deployment_template.yml"}
+	valuesFileList = []string{"testdata/values.yml"}
+	setValueList = setList
+}
+
+func resetRenderTest() {
+	configurationSourceList = nil
+	valuesFileList = nil
+	setValueList = nil
+}
+
+func TestRunRender(t *testing.T) {
+	setupRenderTest("image.tag=2", "replicas=3")
+	defer resetRenderTest()
+
+	out := &bytes.Buffer{}
+	err := runRender(out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "  namespace: default\n")
+	assert.Contains(t, out.String(), "  replicas: 3\n")
+	assert.Contains(t, out.String(), "image: registry.example.com/backend:2\n")
+}
+
+func TestRunRender_Required(t *testing.T) {
+	setupRenderTest("image.tag=")
+	defer resetRenderTest()
+
+	err := runRender(&bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestLoadConfiguration_Template(t *testing.T) {
+	setupRenderTest("image.tag=2")
+	defer resetRenderTest()
+
+	cleanup, err := loadConfiguration()
+	assert.Nil(t, err)
+	defer cleanup()
+
+	// monitor sees rendered configuration
+	resourceList, err := kubectl.ParseLocalFile(configurationYaml)
+	assert.Nil(t, err)
+	deployment, err := resourceList[0].ToDeployment()
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com/backend:2", deployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+// render prints exactly what apply loads, with and without template flags
+func TestRunRender_SameAsApply(t *testing.T) {
+	defer func() {
+		resetRenderTest()
+		templateFlag = false
+	}()
+
+	for _, setup := range []func(){
+		func() { setupRenderTest("image.tag=2") },
+		func() {
+			resetRenderTest()
+			configurationSourceList = []string{"testdata/deployment.yml"}
+		},
+		func() {
+			resetRenderTest()
+			configurationSourceList = []string{"testdata/deployment.yml"}
+			templateFlag = true
+		},
+	} {
+		setup()
+
+		out := &bytes.Buffer{}
+		err := runRender(out)
+		assert.Nil(t, err)
+
+		cleanup, err := loadConfiguration()
+		assert.Nil(t, err)
+		data, err := ioutil.ReadFile(configurationYaml)
+		assert.Nil(t, err)
+		cleanup()
+
+		assert.Equal(t, string(data), out.String())
+	}
+}
+
+func TestRunRender_Patch(t *testing.T) {
+	setupRenderTest("image.tag=2")
+	patchFileList = []string{"testdata/deployment_production.yml"}
+	patchType = kubectl.PatchTypeStrategic
+	defer func() {
+		resetRenderTest()
+		patchFileList = nil
+	}()
+
+	out := &bytes.Buffer{}
+	err := runRender(out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "  replicas: 3\n")
+	assert.Contains(t, out.String(), "image: registry.example.com/backend:2\n")
+	assert.Contains(t, out.String(), "app: backend\n")
+}
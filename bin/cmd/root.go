@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/Dalee/fuse/pkg/kubectl"
 	"github.com/spf13/cobra"
 	"os"
@@ -12,20 +13,31 @@ var (
 		Use:   "fuse",
 		Short: "Kubernetes deploy and maintenance tool",
 		Long:  `Kubernetes deploy and maintenance tool, great for CI/CD environments`,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// override ClusterContextEnv with provided flag
 			if contextFlag != "" {
 				os.Setenv(kubectl.ClusterContextEnv, contextFlag)
 			}
+
+			// override ClusterBackendEnv with provided flag
+			if backendFlag != "" {
+				if backendFlag != kubectl.BackendKubectl && backendFlag != kubectl.BackendAPI {
+					return fmt.Errorf("unknown backend: %s", backendFlag)
+				}
+				os.Setenv(kubectl.ClusterBackendEnv, backendFlag)
+			}
+			return nil
 		},
 	}
 
 	// global flag for current cluster context
 	contextFlag   = ""
+	backendFlag   = ""
 	namespaceFlag = ""
 )
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&contextFlag, "context", "c", "", "Override CLUSTER_CONTEXT defined in environment (default \"\")")
+	RootCmd.PersistentFlags().StringVar(&backendFlag, "backend", "", "Override CLUSTER_BACKEND defined in environment, \"kubectl\" or \"api\" (default \"kubectl\")")
 	execCmd.PersistentFlags().StringVarP(&namespaceFlag, "namespace", "n", "default", "Kubernetes namespace to use")
 }
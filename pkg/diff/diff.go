@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type (
+	// single line of edit script
+	edit struct {
+		op   byte // ' ', '-' or '+'
+		line string
+		from int // line index in "from" text
+		to   int // line index in "to" text
+	}
+)
+
+// Unified return unified diff of two texts with given number of context lines,
+// empty string is returned for equal texts
+func Unified(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+
+	editList := buildEditList(splitLines(from), splitLines(to))
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n", fromName)
+	fmt.Fprintf(buf, "+++ %s\n", toName)
+
+	for start := 0; start < len(editList); {
+		// find first change
+		for start < len(editList) && editList[start].op == ' ' {
+			start++
+		}
+		if start == len(editList) {
+			break
+		}
+
+		// extend hunk while changes are close enough to each other
+		end := start
+		for i := start; i < len(editList); i++ {
+			if editList[i].op != ' ' {
+				if i-end-1 > 2*context {
+					break
+				}
+				end = i
+			}
+		}
+
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+		last := end + context
+		if last >= len(editList) {
+			last = len(editList) - 1
+		}
+
+		writeHunk(buf, editList[first:last+1])
+		start = last + 1
+	}
+
+	return buf.String()
+}
+
+// "@@ -from,count +to,count @@" followed by hunk lines
+func writeHunk(buf *bytes.Buffer, hunk []edit) {
+	fromCount, toCount := 0, 0
+	for _, e := range hunk {
+		if e.op != '+' {
+			fromCount++
+		}
+		if e.op != '-' {
+			toCount++
+		}
+	}
+
+	fromStart, toStart := hunk[0].from+1, hunk[0].to+1
+	if fromCount == 0 {
+		fromStart--
+	}
+	if toCount == 0 {
+		toStart--
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+	for _, e := range hunk {
+		fmt.Fprintf(buf, "%c%s\n", e.op, e.line)
+	}
+}
+
+// longest common subsequence based edit script
+func buildEditList(from, to []string) []edit {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	editList := make([]edit, 0)
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			editList = append(editList, edit{' ', from[i], i, j})
+			i++
+			j++
+		case j == len(to) || (i < len(from) && lcs[i+1][j] >= lcs[i][j+1]):
+			editList = append(editList, edit{'-', from[i], i, j})
+			i++
+		default:
+			editList = append(editList, edit{'+', to[j], i, j})
+			j++
+		}
+	}
+
+	return editList
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
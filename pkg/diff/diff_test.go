@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	assert.Equal(t, "", Unified("a", "b", "line\n", "line\n", 3))
+}
+
+func TestUnified_Changed(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+
+	assert.Equal(t, `--- live
++++ local
+@@ -2,7 +2,8 @@
+ b
+ c
+-d
++D
+ e
+ f
+ g
+ h
++i
+`, Unified("live", "local", from, to, 2))
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	to := "0\n2\n3\n4\n5\n6\n7\n8\n"
+
+	assert.Equal(t, `--- live
++++ local
+@@ -1,2 +1,2 @@
+-1
++0
+ 2
+@@ -8,2 +8,1 @@
+ 8
+-9
+`, Unified("live", "local", from, to, 1))
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	assert.Equal(t, `--- live
++++ local
+@@ -0,0 +1,2 @@
++kind: ConfigMap
++name: backend
+`, Unified("live", "local", "", "kind: ConfigMap\nname: backend\n", 3))
+}
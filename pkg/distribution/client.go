@@ -0,0 +1,167 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// number of repositories requested per catalog page
+	catalogPageSize = 100
+
+	// manifest media types, creation time can be detected from
+	mediaTypeManifestV2   = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeManifestV1   = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+)
+
+type (
+	// Client is Docker Distribution HTTP API V2 client, covering calls Hitman doesn't provide
+	Client struct {
+		url        string
+		httpClient *http.Client
+	}
+
+	// image manifest, only fields required to find image configuration are decoded
+	imageManifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"` // schema 2 and OCI
+		Manifests []struct {
+			Digest string `json:"digest"`
+		} `json:"manifests"` // manifest list and OCI index
+		History []struct {
+			V1Compatibility string `json:"v1Compatibility"`
+		} `json:"history"` // schema 1
+	}
+
+	// catalog page
+	catalogPage struct {
+		Repositories []string `json:"repositories"`
+	}
+
+	// image configuration, only creation time is decoded
+	imageConfig struct {
+		Created time.Time `json:"created"`
+	}
+)
+
+// New creates Client for registry URL (e.g. "https://registry.example.com:5000/"),
+// credentials can be passed within URL, as for Hitman
+func New(registryURL string) *Client {
+	return &Client{
+		url:        strings.TrimRight(registryURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+}
+
+// GetImageCreated return creation time of image manifest, for manifest lists creation time of first image is used
+func (c *Client) GetImageCreated(repo, digest string) (time.Time, error) {
+	manifest := &imageManifest{}
+	accept := []string{mediaTypeManifestV2, mediaTypeManifestList, mediaTypeOCIManifest, mediaTypeOCIIndex, mediaTypeManifestV1}
+	if _, err := c.getJSON(fmt.Sprintf("/v2/%s/manifests/%s", repo, digest), accept, manifest); err != nil {
+		return time.Time{}, err
+	}
+
+	config := &imageConfig{}
+	switch {
+	case manifest.Config.Digest != "":
+		if _, err := c.getJSON(fmt.Sprintf("/v2/%s/blobs/%s", repo, manifest.Config.Digest), nil, config); err != nil {
+			return time.Time{}, err
+		}
+
+	case len(manifest.Manifests) > 0:
+		return c.GetImageCreated(repo, manifest.Manifests[0].Digest)
+
+	case len(manifest.History) > 0:
+		if err := json.Unmarshal([]byte(manifest.History[0].V1Compatibility), config); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if config.Created.IsZero() {
+		return time.Time{}, fmt.Errorf("Unable to detect creation time of %s@%s", repo, digest)
+	}
+
+	return config.Created, nil
+}
+
+// GetCatalog return every repository of registry, pages are followed by Link header
+func (c *Client) GetCatalog() ([]string, error) {
+	repositoryList := make([]string, 0)
+	path := fmt.Sprintf("/v2/_catalog?n=%d", catalogPageSize)
+	for path != "" {
+		page := &catalogPage{}
+		header, err := c.getJSON(path, nil, page)
+		if err != nil {
+			return nil, err
+		}
+		repositoryList = append(repositoryList, page.Repositories...)
+
+		next := nextPage(header.Get("Link"))
+		if next == path {
+			return nil, fmt.Errorf("Catalog pagination loop at %s", path)
+		}
+		path = next
+	}
+
+	return repositoryList, nil
+}
+
+// path of the next page from Link header, e.g. </v2/_catalog?last=repo&n=100>; rel="next",
+// empty string is returned for the last page
+func nextPage(link string) string {
+	if !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+
+	u, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+	return u.RequestURI()
+}
+
+// perform GET request and decode JSON answer, response headers are returned
+func (c *Client) getJSON(path string, accept []string, v interface{}) (http.Header, error) {
+	request, err := http.NewRequest(http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		request.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s failed: %s", path, response.Status)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	return response.Header, nil
+}
@@ -0,0 +1,100 @@
+package distribution
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fake registry, answers are keyed by request path
+func newFakeRegistry(answers map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer, ok := answers[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`))
+			return
+		}
+		w.Write([]byte(answer))
+	}))
+}
+
+func TestClient_GetImageCreated(t *testing.T) {
+	server := newFakeRegistry(map[string]string{
+		"/v2/sample/repo/manifests/sha256:schema2": `{
+			"schemaVersion": 2,
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"config": {"digest": "sha256:config"}
+		}`,
+		"/v2/sample/repo/blobs/sha256:config": `{"created": "2017-07-04T18:49:11.5Z", "architecture": "amd64"}`,
+		"/v2/sample/repo/manifests/sha256:list": `{
+			"schemaVersion": 2,
+			"manifests": [{"digest": "sha256:schema2"}, {"digest": "sha256:other"}]
+		}`,
+		"/v2/sample/repo/manifests/sha256:schema1": `{
+			"schemaVersion": 1,
+			"history": [{"v1Compatibility": "{\"created\": \"2017-07-03T10:00:00Z\"}"}]
+		}`,
+		"/v2/sample/repo/manifests/sha256:empty": `{"schemaVersion": 1}`,
+	})
+	defer server.Close()
+
+	client := New(server.URL + "/")
+
+	created, err := client.GetImageCreated("sample/repo", "sha256:schema2")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 7, 4, 18, 49, 11, 500000000, time.UTC), created.UTC())
+
+	created, err = client.GetImageCreated("sample/repo", "sha256:list")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 7, 4, 18, 49, 11, 500000000, time.UTC), created.UTC())
+
+	created, err = client.GetImageCreated("sample/repo", "sha256:schema1")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 7, 3, 10, 0, 0, 0, time.UTC), created.UTC())
+
+	_, err = client.GetImageCreated("sample/repo", "sha256:empty")
+	assert.EqualError(t, err, "Unable to detect creation time of sample/repo@sha256:empty")
+
+	_, err = client.GetImageCreated("sample/repo", "sha256:unknown")
+	assert.EqualError(t, err, "GET /v2/sample/repo/manifests/sha256:unknown failed: 404 Not Found")
+}
+
+func TestClient_GetCatalog(t *testing.T) {
+	requestList := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestList = append(requestList, r.URL.RequestURI())
+		switch r.URL.Query().Get("last") {
+		case "":
+			w.Header().Set("Link", `</v2/_catalog?last=acme%2Fbackend&n=100>; rel="next"`)
+			w.Write([]byte(`{"repositories": ["acme/api", "acme/backend"]}`))
+		case "acme/backend":
+			w.Header().Set("Link", `<http://`+r.Host+`/v2/_catalog?last=base%2Falpine&n=100>; rel="next"`)
+			w.Write([]byte(`{"repositories": ["base/alpine"]}`))
+		default:
+			w.Write([]byte(`{"repositories": []}`))
+		}
+	}))
+	defer server.Close()
+
+	repositoryList, err := New(server.URL).GetCatalog()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"acme/api", "acme/backend", "base/alpine"}, repositoryList)
+	assert.Equal(t, []string{
+		"/v2/_catalog?n=100",
+		"/v2/_catalog?last=acme%2Fbackend&n=100",
+		"/v2/_catalog?last=base%2Falpine&n=100",
+	}, requestList)
+}
+
+func TestClient_GetCatalogFailed(t *testing.T) {
+	server := newFakeRegistry(map[string]string{})
+	defer server.Close()
+
+	repositoryList, err := New(server.URL).GetCatalog()
+	assert.Nil(t, repositoryList)
+	assert.EqualError(t, err, "GET /v2/_catalog?n=100 failed: 404 Not Found")
+}
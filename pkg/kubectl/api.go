@@ -1,11 +1,23 @@
 package kubectl
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 )
 
+const (
+	// DryRunClient only validates configuration locally, nothing is sent to cluster
+	DryRunClient = "client"
+
+	// DryRunServer sends configuration to cluster, but changes are not persisted
+	DryRunServer = "server"
+
+	// AllNamespaces is namespace of list calls, which return resources of every namespace
+	AllNamespaces = "*"
+)
+
 type (
 	// KubeCall is kubectl wrapper combined with parser
 	KubeCall struct {
@@ -14,9 +26,9 @@ type (
 	}
 )
 
-// RunPlain run command and just return command output
-func (c *KubeCall) RunPlain() ([]byte, error) {
-	output, success := c.Cmd.Run()
+// RunPlain run command and just return command output, command is killed when context is done
+func (c *KubeCall) RunPlain(ctx context.Context) ([]byte, error) {
+	output, success := c.Cmd.Run(ctx)
 	if success != true {
 		return output, errors.New(string(output))
 	}
@@ -25,8 +37,8 @@ func (c *KubeCall) RunPlain() ([]byte, error) {
 }
 
 // RunAndParse run command and try to parse output with provided parser
-func (c *KubeCall) RunAndParse() (ResourceList, error) {
-	output, err := c.RunPlain()
+func (c *KubeCall) RunAndParse(ctx context.Context) (ResourceList, error) {
+	output, err := c.RunPlain(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +52,8 @@ func (c *KubeCall) RunAndParse() (ResourceList, error) {
 }
 
 // RunAndParseFirst run command, parse output and return first element of decoded items
-func (c *KubeCall) RunAndParseFirst() (KubeResourceInterface, error) {
-	items, err := c.RunAndParse()
+func (c *KubeCall) RunAndParseFirst(ctx context.Context) (KubeResourceInterface, error) {
+	items, err := c.RunAndParse(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +65,11 @@ func (c *KubeCall) RunAndParseFirst() (KubeResourceInterface, error) {
 	return nil, nil
 }
 
+// IsNotFound check error reports missing resource
+func IsNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "NotFound")
+}
+
 // CommandApply apply new yaml configuration to cluster
 func CommandApply(configurationYaml string) *KubeCall {
 	p := newParser()
@@ -70,6 +87,25 @@ func CommandApply(configurationYaml string) *KubeCall {
 	}
 }
 
+// CommandApplyDryRun apply configuration in dry-run mode (DryRunClient or DryRunServer),
+// resulting objects are returned as yaml list
+func CommandApplyDryRun(configurationYaml, mode string) *KubeCall {
+	p := newParser()
+	c := newCommand([]string{
+		"apply",
+		"-f",
+		configurationYaml,
+		fmt.Sprintf("--dry-run=%s", mode),
+		"-o",
+		"yaml",
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
 // CommandRollback allow to rollback any resource to previous version
 func CommandRollback(namespace, kind, name string) *KubeCall {
 	p := newParser()
@@ -86,6 +122,38 @@ func CommandRollback(namespace, kind, name string) *KubeCall {
 	}
 }
 
+// CommandRollbackToRevision allow to rollback any resource to exact revision
+func CommandRollbackToRevision(namespace, kind, name string, revision int) *KubeCall {
+	p := newParser()
+	c := newCommand([]string{
+		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		"rollout",
+		"undo",
+		fmt.Sprintf("%s/%s", kind, name),
+		fmt.Sprintf("--to-revision=%d", revision),
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
+// CommandDelete delete any resource
+func CommandDelete(namespace, kind, name string) *KubeCall {
+	p := newParser()
+	c := newCommand([]string{
+		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		"delete",
+		fmt.Sprintf("%s/%s", kind, name),
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
 // CommandExec execute command in container of pod
 func CommandExec(namespace, pod, container, command string) *KubeCall {
 	p := newParser()
@@ -123,7 +191,7 @@ func CommandNamespaceList() *KubeCall {
 func CommandReplicaSetList(namespace string) *KubeCall {
 	p := newParser()
 	c := newCommand([]string{
-		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		formatNamespaceFlag(namespace),
 		"get",
 		"replicasets",
 		"-o",
@@ -141,7 +209,7 @@ func CommandReplicaSetListBySelector(namespace string, selector []string) *KubeC
 	selectorList := strings.Join(selector, ",")
 	p := newParser()
 	c := newCommand([]string{
-		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		formatNamespaceFlag(namespace),
 		"get",
 		"replicasets",
 		fmt.Sprintf("--selector=%s", selectorList),
@@ -172,11 +240,64 @@ func CommandDeploymentInfo(namespace string, name string) *KubeCall {
 	}
 }
 
+// CommandResourceInfo get information about single resource of any kind
+func CommandResourceInfo(namespace, kind, name string) *KubeCall {
+	p := newParser()
+	c := newCommand([]string{
+		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		"get",
+		fmt.Sprintf("%s/%s", kind, name),
+		"-o",
+		"yaml",
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
+// CommandResourceList get list of resources of any kind
+func CommandResourceList(namespace, kind string) *KubeCall {
+	p := newParser()
+	c := newCommand([]string{
+		formatNamespaceFlag(namespace),
+		"get",
+		kind,
+		"-o",
+		"yaml",
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
+// CommandControllerRevisionListBySelector get controller revision list (StatefulSet and DaemonSet history) by selector
+func CommandControllerRevisionListBySelector(namespace string, selector []string) *KubeCall {
+	selectorList := strings.Join(selector, ",")
+	p := newParser()
+	c := newCommand([]string{
+		formatNamespaceFlag(namespace),
+		"get",
+		"controllerrevisions",
+		fmt.Sprintf("--selector=%s", selectorList),
+		"-o",
+		"yaml",
+	})
+
+	return &KubeCall{
+		Cmd:    c,
+		Parser: p,
+	}
+}
+
 // CommandDeploymentList return call which return list of deployments registered in kubernetes clusted
 func CommandDeploymentList(namespace string) *KubeCall {
 	p := newParser()
 	c := newCommand([]string{
-		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		formatNamespaceFlag(namespace),
 		"get",
 		"deployments",
 		"-o",
@@ -194,7 +315,7 @@ func CommandDeploymentListBySelector(namespace string, selector []string) *KubeC
 	selectorList := strings.Join(selector, ",")
 	p := newParser()
 	c := newCommand([]string{
-		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		formatNamespaceFlag(namespace),
 		"get",
 		"deployment",
 		fmt.Sprintf("--selector=%s", selectorList),
@@ -213,7 +334,7 @@ func CommandPodListBySelector(namespace string, selector []string) *KubeCall {
 	selectorList := strings.Join(selector, ",")
 	p := newParser()
 	c := newCommand([]string{
-		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		formatNamespaceFlag(namespace),
 		"get",
 		"pods",
 		fmt.Sprintf("--selector=%s", selectorList),
@@ -250,3 +371,11 @@ func formatNamespace(namespace string) string {
 	}
 	return namespace
 }
+
+// namespace flag of list commands, AllNamespaces lists resources of every namespace
+func formatNamespaceFlag(namespace string) string {
+	if namespace == AllNamespaces {
+		return "--all-namespaces"
+	}
+	return fmt.Sprintf("--namespace=%s", formatNamespace(namespace))
+}
@@ -1,6 +1,7 @@
 package kubectl
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,7 +24,7 @@ type (
 func (cm *kubeCommandMock) Log() {
 }
 
-func (cm *kubeCommandMock) Run() ([]byte, bool) {
+func (cm *kubeCommandMock) Run(ctx context.Context) ([]byte, bool) {
 	args := cm.Called()
 	return args.Get(0).([]byte), args.Bool(1)
 }
@@ -53,7 +54,7 @@ func TestKubeCall_RunPlain(t *testing.T) {
 		Parser: nil,
 	}
 
-	output, err := call.RunPlain()
+	output, err := call.RunPlain(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, []byte("Hello world"), output)
 }
@@ -70,7 +71,7 @@ func TestKubeCall_RunNormal(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	items, err := call.RunAndParse()
+	items, err := call.RunAndParse(context.Background())
 	assert.Nil(t, err)
 	assert.Len(t, items, 0)
 }
@@ -92,7 +93,7 @@ func TestKubeCall_RunAndParseFirst(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	item, err := call.RunAndParseFirst()
+	item, err := call.RunAndParseFirst(context.Background())
 	assert.Nil(t, err)
 	assert.NotNil(t, item)
 	assert.Equal(t, "namespace", item.GetKind())
@@ -110,7 +111,7 @@ func TestKubeCall_RunAndParseFirstEmpty(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	item, err := call.RunAndParseFirst()
+	item, err := call.RunAndParseFirst(context.Background())
 	assert.Nil(t, err)
 	assert.Nil(t, item)
 }
@@ -127,7 +128,7 @@ func TestKubeCall_RunAndParseFirstError(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	item, err := call.RunAndParseFirst()
+	item, err := call.RunAndParseFirst(context.Background())
 	assert.Error(t, err)
 	assert.Nil(t, item)
 }
@@ -144,7 +145,7 @@ func TestKubeCall_RunCommandFailed(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	items, err := call.RunAndParse()
+	items, err := call.RunAndParse(context.Background())
 	assert.Error(t, err, "Command exited with non-zero status")
 	assert.Nil(t, items)
 }
@@ -161,7 +162,7 @@ func TestKubeCall_RunParserFailed(t *testing.T) {
 		Parser: parserMock,
 	}
 
-	items, err := call.RunAndParse()
+	items, err := call.RunAndParse(context.Background())
 	assert.Error(t, err)
 	assert.Nil(t, items)
 }
@@ -173,6 +174,13 @@ func TestCommandApply(t *testing.T) {
 	assert.Equal(t, "kubectl apply -f test.yaml -o name", args)
 }
 
+func TestCommandApplyDryRun(t *testing.T) {
+	cmd := CommandApplyDryRun("test.yaml", DryRunServer)
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl apply -f test.yaml --dry-run=server -o yaml", args)
+}
+
 func TestCommandRollback(t *testing.T) {
 	cmd := CommandRollback("default", "deployment", "example-deployment")
 
@@ -180,6 +188,20 @@ func TestCommandRollback(t *testing.T) {
 	assert.Equal(t, "kubectl --namespace=default rollout undo deployment/example-deployment", args)
 }
 
+func TestCommandRollbackToRevision(t *testing.T) {
+	cmd := CommandRollbackToRevision("default", "deployment", "example-deployment", 3)
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --namespace=default rollout undo deployment/example-deployment --to-revision=3", args)
+}
+
+func TestCommandDelete(t *testing.T) {
+	cmd := CommandDelete("", "deployment", "example-deployment")
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --namespace=default delete deployment/example-deployment", args)
+}
+
 func TestCommandNamespaceList(t *testing.T) {
 	cmd := CommandNamespaceList()
 
@@ -210,6 +232,13 @@ func TestCommandReplicaSetListWithDefaultNamespace(t *testing.T) {
 	assert.Equal(t, "kubectl --namespace=default get replicasets -o yaml", args)
 }
 
+func TestCommandReplicaSetListWithAllNamespaces(t *testing.T) {
+	cmd := CommandReplicaSetList(AllNamespaces)
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --all-namespaces get replicasets -o yaml", args)
+}
+
 func TestCommandDescribeDeployment(t *testing.T) {
 	cmd := CommandDeploymentInfo("sample-namespace", "example")
 
@@ -217,6 +246,20 @@ func TestCommandDescribeDeployment(t *testing.T) {
 	assert.Equal(t, "kubectl --namespace=sample-namespace get deployment/example -o yaml", args)
 }
 
+func TestCommandResourceInfo(t *testing.T) {
+	cmd := CommandResourceInfo("", "statefulset", "example")
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --namespace=default get statefulset/example -o yaml", args)
+}
+
+func TestCommandControllerRevisionListBySelector(t *testing.T) {
+	cmd := CommandControllerRevisionListBySelector("kube-system", []string{"app=example-app"})
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --namespace=kube-system get controllerrevisions --selector=app=example-app -o yaml", args)
+}
+
 func TestCommandDeploymentList(t *testing.T) {
 	cmd := CommandDeploymentList("kube-system")
 
@@ -238,9 +281,35 @@ func TestCommandPodList(t *testing.T) {
 	assert.Equal(t, "kubectl --namespace=default get pods --selector=app=prod-v1,name=example -o yaml", args)
 }
 
+func TestCommandPodListBySetBasedSelector(t *testing.T) {
+	selector := LabelSelector{
+		MatchLabels: map[string]string{"app": "example"},
+		MatchExpressions: []LabelSelectorRequirement{
+			{Key: "tier", Operator: SelectorOpIn, Values: []string{"web", "api"}},
+		},
+	}
+	cmd := CommandPodListBySelector("", selector.GetSelector())
+
+	args := cmd.Cmd.getCommand().Args
+	assert.Equal(t, "--selector=app=example,tier in (web,api)", args[4])
+}
+
 func TestCommandPodLogs(t *testing.T) {
 	cmd := CommandPodLogs("", "pod-123456", "sysctl-buddy")
 
 	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
 	assert.Equal(t, "kubectl --namespace=default logs --tail=100 --container=sysctl-buddy pod-123456", args)
 }
+
+func TestIsNotFound(t *testing.T) {
+	assert.False(t, IsNotFound(nil))
+	assert.False(t, IsNotFound(errors.New("connection refused")))
+	assert.True(t, IsNotFound(errors.New(`Error from server (NotFound): deployments.apps "example" not found`)))
+}
+
+func TestCommandResourceList(t *testing.T) {
+	cmd := CommandResourceList(AllNamespaces, KindCronJob)
+
+	args := strings.Join(cmd.Cmd.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --all-namespaces get cronjob -o yaml", args)
+}
@@ -0,0 +1,180 @@
+package kubectl
+
+import (
+	"context"
+	"os"
+)
+
+type (
+	// Cluster is an interface to all cluster operations used by fuse commands
+	Cluster interface {
+		GetResource(ctx context.Context, namespace, kind, name string) (KubeResourceInterface, error)
+		GetDeployment(ctx context.Context, namespace, name string) (*Deployment, error)
+		GetManifest(ctx context.Context, namespace, kind, name string) ([]byte, error)
+		ListDeployments(ctx context.Context, namespace string, selector []string) ([]Deployment, error)
+		ListPods(ctx context.Context, namespace string, selector []string) ([]Pod, error)
+		ListReplicaSets(ctx context.Context, namespace string, selector []string) ([]ReplicaSet, error)
+		ListControllerRevisions(ctx context.Context, namespace string, selector []string) ([]ControllerRevision, error)
+		ListResources(ctx context.Context, namespace, kind string) (ResourceList, error)
+		Apply(ctx context.Context, configurationYaml string) ([]byte, error)
+		ApplyDryRun(ctx context.Context, configurationYaml, mode string) ([]byte, error)
+		Undo(ctx context.Context, namespace, kind, name string, toRevision int) ([]byte, error)
+		Delete(ctx context.Context, namespace, kind, name string) ([]byte, error)
+		Logs(ctx context.Context, namespace, pod, container string) ([]byte, error)
+		Exec(ctx context.Context, namespace, pod, container, command string) ([]byte, error)
+		Watch(ctx context.Context, namespace, kind string) (ResourceWatch, error)
+	}
+
+	// Cluster implementation built on top of KubeCall, runs kubectl binary
+	kubeCluster struct {
+		contextName string // kubeconfig context, current one if empty
+	}
+)
+
+// NewCluster return Cluster implementation of backend selected by ClusterBackendEnv,
+// for cluster context of ClusterContextEnv
+func NewCluster() Cluster {
+	return NewClusterForContext("")
+}
+
+// NewClusterForContext return Cluster implementation of backend selected by ClusterBackendEnv,
+// for given cluster context, empty context is the one of ClusterContextEnv
+func NewClusterForContext(contextName string) Cluster {
+	if contextName == "" {
+		contextName = os.Getenv(ClusterContextEnv)
+	}
+
+	if os.Getenv(ClusterBackendEnv) == BackendAPI {
+		return newAPICluster(contextName)
+	}
+	return &kubeCluster{contextName: contextName}
+}
+
+// point kubectl call to cluster context
+func (c *kubeCluster) call(k *KubeCall) *KubeCall {
+	if c.contextName != "" {
+		setCommandContext(k.Cmd, c.contextName)
+	}
+	return k
+}
+
+// GetResource fetch single resource of any kind, nil is returned for unknown kinds
+func (c *kubeCluster) GetResource(ctx context.Context, namespace, kind, name string) (KubeResourceInterface, error) {
+	return c.call(CommandResourceInfo(namespace, kind, name)).RunAndParseFirst(ctx)
+}
+
+// GetDeployment fetch single deployment
+func (c *kubeCluster) GetDeployment(ctx context.Context, namespace, name string) (*Deployment, error) {
+	r, err := c.call(CommandDeploymentInfo(namespace, name)).RunAndParseFirst(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	return r.ToDeployment()
+}
+
+// GetManifest fetch live resource as yaml, which can be applied back to cluster
+func (c *kubeCluster) GetManifest(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	data, err := c.call(CommandResourceInfo(namespace, kind, name)).RunPlain(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cleanManifest(data)
+}
+
+// ListDeployments fetch deployments matching selector
+func (c *kubeCluster) ListDeployments(ctx context.Context, namespace string, selector []string) ([]Deployment, error) {
+	call := c.call(CommandDeploymentList(namespace))
+	if len(selector) > 0 {
+		call = c.call(CommandDeploymentListBySelector(namespace, selector))
+	}
+
+	rlist, err := call.RunAndParse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToDeploymentList(), nil
+}
+
+// ListPods fetch pods matching selector
+func (c *kubeCluster) ListPods(ctx context.Context, namespace string, selector []string) ([]Pod, error) {
+	rlist, err := c.call(CommandPodListBySelector(namespace, selector)).RunAndParse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToPodList(), nil
+}
+
+// ListReplicaSets fetch replica sets matching selector, every replica set is returned for empty selector
+func (c *kubeCluster) ListReplicaSets(ctx context.Context, namespace string, selector []string) ([]ReplicaSet, error) {
+	call := c.call(CommandReplicaSetList(namespace))
+	if len(selector) > 0 {
+		call = c.call(CommandReplicaSetListBySelector(namespace, selector))
+	}
+
+	rlist, err := call.RunAndParse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToReplicaSetList(), nil
+}
+
+// ListControllerRevisions fetch controller revisions matching selector
+func (c *kubeCluster) ListControllerRevisions(ctx context.Context, namespace string, selector []string) ([]ControllerRevision, error) {
+	rlist, err := c.call(CommandControllerRevisionListBySelector(namespace, selector)).RunAndParse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToControllerRevisionList(), nil
+}
+
+// ListResources fetch every resource of given kind
+func (c *kubeCluster) ListResources(ctx context.Context, namespace, kind string) (ResourceList, error) {
+	return c.call(CommandResourceList(namespace, kind)).RunAndParse(ctx)
+}
+
+// Apply apply configuration file to cluster
+func (c *kubeCluster) Apply(ctx context.Context, configurationYaml string) ([]byte, error) {
+	return c.call(CommandApply(configurationYaml)).RunPlain(ctx)
+}
+
+// ApplyDryRun apply configuration file without persisting changes, resulting objects are returned
+func (c *kubeCluster) ApplyDryRun(ctx context.Context, configurationYaml, mode string) ([]byte, error) {
+	return c.call(CommandApplyDryRun(configurationYaml, mode)).RunPlain(ctx)
+}
+
+// Undo rollback resource to exact revision, or to previous one if toRevision is 0
+func (c *kubeCluster) Undo(ctx context.Context, namespace, kind, name string, toRevision int) ([]byte, error) {
+	if toRevision > 0 {
+		return c.call(CommandRollbackToRevision(namespace, kind, name, toRevision)).RunPlain(ctx)
+	}
+	return c.call(CommandRollback(namespace, kind, name)).RunPlain(ctx)
+}
+
+// Delete delete resource
+func (c *kubeCluster) Delete(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	return c.call(CommandDelete(namespace, kind, name)).RunPlain(ctx)
+}
+
+// Logs fetch logs of pod container
+func (c *kubeCluster) Logs(ctx context.Context, namespace, pod, container string) ([]byte, error) {
+	return c.call(CommandPodLogs(namespace, pod, container)).RunPlain(ctx)
+}
+
+// Exec execute command in pod container
+func (c *kubeCluster) Exec(ctx context.Context, namespace, pod, container, command string) ([]byte, error) {
+	return c.call(CommandExec(namespace, pod, container, command)).RunPlain(ctx)
+}
+
+// Watch stream changes of resources of kind in namespace
+func (c *kubeCluster) Watch(ctx context.Context, namespace, kind string) (ResourceWatch, error) {
+	return newKubectlWatch(ctx, c.contextName, namespace, kind)
+}
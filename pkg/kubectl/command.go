@@ -1,6 +1,8 @@
 package kubectl
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,7 +11,7 @@ import (
 
 type (
 	kubeCommandInterface interface {
-		Run() ([]byte, bool)
+		Run(ctx context.Context) ([]byte, bool)
 		getCommand() *exec.Cmd
 	}
 
@@ -36,11 +38,45 @@ func newCommandWithBinary(args []string, binary string) *kubeCommand {
 	}
 }
 
-// Execute command and get stdout, stderr and exit_code as bool
-func (c *kubeCommand) Run() ([]byte, bool) {
+// point command to cluster context, context taken from environment is replaced
+func setCommandContext(c kubeCommandInterface, contextName string) {
+	cmd := c.getCommand()
+	argList := []string{cmd.Args[0], fmt.Sprintf("--context=%s", contextName)}
+	for _, arg := range cmd.Args[1:] {
+		if !strings.HasPrefix(arg, "--context=") {
+			argList = append(argList, arg)
+		}
+	}
+	cmd.Args = argList
+}
+
+// Execute command and get stdout, stderr and exit_code as bool,
+// process is killed when context is done
+func (c *kubeCommand) Run(ctx context.Context) ([]byte, bool) {
 	fmt.Printf("===> %s\n", strings.Join(c.getCommand().Args, " ")) // TODO: should be moved to logging
 
-	result, err := c.getCommand().CombinedOutput()
+	output := &bytes.Buffer{}
+	cmd := c.getCommand()
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Start()
+	if err == nil {
+		waitResult := make(chan error, 1)
+		go func() {
+			waitResult <- cmd.Wait()
+		}()
+
+		select {
+		case err = <-waitResult:
+		case <-ctx.Done():
+			cmd.Process.Kill()
+			<-waitResult
+			err = ctx.Err()
+		}
+	}
+
+	result := output.Bytes()
 	if len(result) == 0 {
 		result = []byte("Command failed to run")
 	}
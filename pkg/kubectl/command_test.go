@@ -1,10 +1,12 @@
 package kubectl
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 // ensure command will be created without cluster context
@@ -31,16 +33,30 @@ func TestCreateCommandContext(t *testing.T) {
 	os.Unsetenv(ClusterContextEnv)
 }
 
+// ensure context of environment is replaced by cluster context
+func TestSetCommandContext(t *testing.T) {
+	os.Setenv(ClusterContextEnv, "live-context")
+	cliCommand := newCommand([]string{
+		"hello",
+		"world",
+	})
+	os.Unsetenv(ClusterContextEnv)
+
+	setCommandContext(cliCommand, "staging")
+	cmdString := strings.Join(cliCommand.getCommand().Args, " ")
+	assert.Equal(t, "kubectl --context=staging hello world", cmdString)
+}
+
 // ensure command can be executed
 func TestExecuteCommand(t *testing.T) {
 	cliCommand := newCommandWithBinary([]string{"/"}, "ls")
-	_, ok := cliCommand.Run()
+	_, ok := cliCommand.Run(context.Background())
 	assert.True(t, ok)
 }
 
 func TestExecuteCommandFailed(t *testing.T) {
 	cliCommand := newCommandWithBinary([]string{"/"}, "_non_existent_command_")
-	result, ok := cliCommand.Run()
+	result, ok := cliCommand.Run(context.Background())
 
 	assert.False(t, ok)
 	assert.Equal(t, []byte("Command failed to run"), result)
@@ -52,3 +68,15 @@ func TestGetCommand(t *testing.T) {
 
 	assert.Equal(t, []string{"ls", "/"}, cmd.Args)
 }
+
+func TestExecuteCommandCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	cliCommand := newCommandWithBinary([]string{"10"}, "sleep")
+	_, ok := cliCommand.Run(ctx)
+
+	assert.False(t, ok)
+	assert.True(t, time.Since(startedAt) < 5*time.Second)
+}
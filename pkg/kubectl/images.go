@@ -0,0 +1,52 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// every kind of resource defining pods, bare pods go first, so their image IDs are listed next to images
+	workloadKindList = []string{
+		KindPod,
+		KindReplicaSet,
+		KindDeployment,
+		KindStatefulSet,
+		KindDaemonSet,
+		KindJob,
+		KindCronJob,
+	}
+)
+
+// CollectImages return every image in use within namespace (or AllNamespaces): images of containers
+// and init containers of every workload kind and image digest references reported by pods statuses,
+// so images are still known, even if tags have been moved since pods were started. Duplicates are removed,
+// order is stable. Failure to list any kind is an error, partial list is worse than none.
+func CollectImages(ctx context.Context, cluster Cluster, namespace string) ([]string, error) {
+	imageList := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(items []string) {
+		for _, image := range items {
+			if !seen[image] {
+				seen[image] = true
+				imageList = append(imageList, image)
+			}
+		}
+	}
+
+	for _, kind := range workloadKindList {
+		rlist, err := cluster.ListResources(ctx, namespace, kind)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list %s: %s", kind, err)
+		}
+
+		for _, r := range rlist.ToImageResourceList() {
+			add(r.GetImages())
+			if pod, ok := r.(*Pod); ok {
+				add(pod.GetImageIDs())
+			}
+		}
+	}
+
+	return imageList, nil
+}
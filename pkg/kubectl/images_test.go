@@ -0,0 +1,50 @@
+package kubectl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Dalee/fuse/pkg/kubectl"
+	"github.com/Dalee/fuse/pkg/kubectl/kubectltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWorkloadCluster(t *testing.T) *kubectltest.FakeCluster {
+	resources, err := kubectl.ParseLocalFile("./testdata/workloads.yml")
+	assert.Nil(t, err)
+
+	return kubectltest.NewFakeCluster(resources...)
+}
+
+func TestCollectImages(t *testing.T) {
+	imageList, err := kubectl.CollectImages(context.Background(), newWorkloadCluster(t), "default")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"busybox",
+		"busybox@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+		"registry.example.com/backend-migrate:v1",
+		"registry.example.com/backend:v1",
+		"registry.example.com/backend-migrate@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+		"registry.example.com/database-init:v1",
+		"registry.example.com/database:v1",
+		"registry.example.com/import:v1",
+		"registry.example.com/cleanup:v1",
+	}, imageList)
+}
+
+func TestCollectImages_AllNamespaces(t *testing.T) {
+	imageList, err := kubectl.CollectImages(context.Background(), newWorkloadCluster(t), kubectl.AllNamespaces)
+	assert.Nil(t, err)
+	assert.Contains(t, imageList, "registry.example.com/agent:v1")
+	assert.Len(t, imageList, 10)
+}
+
+func TestCollectImages_ListFailed(t *testing.T) {
+	cluster := newWorkloadCluster(t)
+	cluster.ListErr = errors.New("forbidden")
+
+	imageList, err := kubectl.CollectImages(context.Background(), cluster, "default")
+	assert.Nil(t, imageList)
+	assert.EqualError(t, err, "unable to list pod: forbidden")
+}
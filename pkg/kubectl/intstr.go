@@ -0,0 +1,81 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type (
+	// IntOrString is a value which can hold either an int or a string,
+	// used by rolling update parameters, e.g. maxSurge: 1 or maxUnavailable: 25%
+	IntOrString struct {
+		IsString bool
+		IntVal   int
+		StrVal   string
+	}
+)
+
+// IntOrStringFromInt creates IntOrString holding an int value
+func IntOrStringFromInt(value int) IntOrString {
+	return IntOrString{IntVal: value}
+}
+
+// IntOrStringFromString creates IntOrString holding a string value
+func IntOrStringFromString(value string) IntOrString {
+	return IntOrString{IsString: true, StrVal: value}
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface, yaml is converted to json before decoding
+func (v *IntOrString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		v.IsString = true
+		v.IntVal = 0
+		return json.Unmarshal(data, &v.StrVal)
+	}
+
+	v.IsString = false
+	v.StrVal = ""
+	return json.Unmarshal(data, &v.IntVal)
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (v IntOrString) MarshalJSON() ([]byte, error) {
+	if v.IsString {
+		return json.Marshal(v.StrVal)
+	}
+	return json.Marshal(v.IntVal)
+}
+
+// String return string representation of value
+func (v IntOrString) String() string {
+	if v.IsString {
+		return v.StrVal
+	}
+	return strconv.Itoa(v.IntVal)
+}
+
+// GetScaledValue return absolute value, percentage is calculated against total
+// and rounded up or down, same way as Kubernetes does
+func (v IntOrString) GetScaledValue(total int, roundUp bool) (int, error) {
+	if !v.IsString {
+		return v.IntVal, nil
+	}
+
+	if !strings.HasSuffix(v.StrVal, "%") {
+		return 0, fmt.Errorf("invalid value for IntOrString: %q, percentage expected", v.StrVal)
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(v.StrVal, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for IntOrString: %q, percentage expected", v.StrVal)
+	}
+
+	value := float64(percent) * float64(total) / 100
+	if roundUp {
+		return int(math.Ceil(value)), nil
+	}
+	return int(math.Floor(value)), nil
+}
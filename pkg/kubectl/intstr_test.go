@@ -0,0 +1,59 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIntOrString_Unmarshal(t *testing.T) {
+	var value struct {
+		MaxSurge       IntOrString
+		MaxUnavailable IntOrString
+	}
+
+	err := json.Unmarshal([]byte(`{"maxSurge": 2, "maxUnavailable": "25%"}`), &value)
+	assert.Nil(t, err)
+	assert.Equal(t, IntOrStringFromInt(2), value.MaxSurge)
+	assert.Equal(t, IntOrStringFromString("25%"), value.MaxUnavailable)
+	assert.Equal(t, "2", value.MaxSurge.String())
+	assert.Equal(t, "25%", value.MaxUnavailable.String())
+
+	err = json.Unmarshal([]byte(`{"maxSurge": true}`), &value)
+	assert.Error(t, err)
+}
+
+func TestIntOrString_Marshal(t *testing.T) {
+	data, err := json.Marshal([]IntOrString{IntOrStringFromInt(1), IntOrStringFromString("50%")})
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,"50%"]`, string(data))
+}
+
+func TestIntOrString_GetScaledValue(t *testing.T) {
+	testCaseList := []struct {
+		value    IntOrString
+		total    int
+		roundUp  bool
+		expected int
+		isError  bool
+	}{
+		{value: IntOrStringFromInt(3), total: 10, expected: 3},
+		{value: IntOrStringFromString("25%"), total: 10, roundUp: true, expected: 3},
+		{value: IntOrStringFromString("25%"), total: 10, roundUp: false, expected: 2},
+		{value: IntOrStringFromString("100%"), total: 3, roundUp: false, expected: 3},
+		{value: IntOrStringFromString("0%"), total: 3, roundUp: true, expected: 0},
+		{value: IntOrStringFromString("25"), total: 10, isError: true},
+		{value: IntOrStringFromString("a%"), total: 10, isError: true},
+	}
+
+	for _, testCase := range testCaseList {
+		result, err := testCase.value.GetScaledValue(testCase.total, testCase.roundUp)
+		if testCase.isError {
+			assert.Error(t, err, testCase.value.String())
+			continue
+		}
+
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, result, testCase.value.String())
+	}
+}
@@ -0,0 +1,198 @@
+package kubectl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+type (
+	// ghodss/yaml decodes documents through encoding/json,
+	// so json tags are required for dashed kubeconfig keys
+	kubeConfigCluster struct {
+		Server                   string `json:"server"`
+		CertificateAuthority     string `json:"certificate-authority"`
+		CertificateAuthorityData string `json:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+	}
+
+	kubeConfigUser struct {
+		Token                 string `json:"token"`
+		TokenFile             string `json:"tokenFile"`
+		Username              string `json:"username"`
+		Password              string `json:"password"`
+		ClientCertificate     string `json:"client-certificate"`
+		ClientCertificateData string `json:"client-certificate-data"`
+		ClientKey             string `json:"client-key"`
+		ClientKeyData         string `json:"client-key-data"`
+	}
+
+	kubeConfigContext struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		Namespace string `json:"namespace"`
+	}
+
+	kubeConfig struct {
+		CurrentContext string `json:"current-context"`
+		Clusters       []struct {
+			Name    string            `json:"name"`
+			Cluster kubeConfigCluster `json:"cluster"`
+		} `json:"clusters"`
+		Users []struct {
+			Name string         `json:"name"`
+			User kubeConfigUser `json:"user"`
+		} `json:"users"`
+		Contexts []struct {
+			Name    string            `json:"name"`
+			Context kubeConfigContext `json:"context"`
+		} `json:"contexts"`
+	}
+)
+
+// find kubeconfig file the same way kubectl does: $KUBECONFIG or ~/.kube/config
+func getKubeConfigPath() string {
+	for _, path := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".kube", "config")
+}
+
+// load kubeconfig and build API client for requested context,
+// current-context is used if context is empty
+func newAPIClientFromKubeConfig(path, context string) (*apiClient, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &kubeConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	if context == "" {
+		context = config.CurrentContext
+	}
+
+	var ctx *kubeConfigContext
+	for i := range config.Contexts {
+		if config.Contexts[i].Name == context {
+			ctx = &config.Contexts[i].Context
+		}
+	}
+	if ctx == nil {
+		return nil, fmt.Errorf("context %q is not found in %s", context, path)
+	}
+
+	var cluster *kubeConfigCluster
+	for i := range config.Clusters {
+		if config.Clusters[i].Name == ctx.Cluster {
+			cluster = &config.Clusters[i].Cluster
+		}
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %q is not found in %s", ctx.Cluster, path)
+	}
+
+	user := &kubeConfigUser{}
+	for i := range config.Users {
+		if config.Users[i].Name == ctx.User {
+			user = &config.Users[i].User
+		}
+	}
+
+	// relative file references are resolved against kubeconfig location
+	baseDir := filepath.Dir(path)
+	tlsConfig, err := buildTLSConfig(cluster, user, baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	token := user.Token
+	if token == "" && user.TokenFile != "" {
+		tokenData, err := ioutil.ReadFile(resolvePath(baseDir, user.TokenFile))
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(tokenData))
+	}
+
+	return &apiClient{
+		server:    strings.TrimRight(cluster.Server, "/"),
+		token:     token,
+		username:  user.Username,
+		password:  user.Password,
+		tlsConfig: tlsConfig,
+		httpClient: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// build TLS configuration from cluster and user credentials
+func buildTLSConfig(cluster *kubeConfigCluster, user *kubeConfigUser, baseDir string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cluster.InsecureSkipTLSVerify,
+	}
+
+	caData, err := readDataOrFile(cluster.CertificateAuthorityData, cluster.CertificateAuthority, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if caData != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.New("unable to load certificate authority from kubeconfig")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certData, err := readDataOrFile(user.ClientCertificateData, user.ClientCertificate, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := readDataOrFile(user.ClientKeyData, user.ClientKey, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if certData != nil && keyData != nil {
+		cert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// kubeconfig allows to provide either base64 encoded data or file path
+func readDataOrFile(data, file, baseDir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return ioutil.ReadFile(resolvePath(baseDir, file))
+	}
+	return nil, nil
+}
+
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
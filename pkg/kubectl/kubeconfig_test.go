@@ -0,0 +1,34 @@
+package kubectl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewAPIClientFromKubeConfig_CurrentContext(t *testing.T) {
+	client, err := newAPIClientFromKubeConfig("./testdata/kubeconfig.yml", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://staging.example.com:6443", client.server)
+	assert.Equal(t, "staging-token", client.token)
+}
+
+func TestNewAPIClientFromKubeConfig_Context(t *testing.T) {
+	client, err := newAPIClientFromKubeConfig("./testdata/kubeconfig.yml", "production")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://production.example.com:6443", client.server)
+	assert.Equal(t, "", client.token)
+	assert.Equal(t, "admin", client.username)
+	assert.Equal(t, "secret", client.password)
+}
+
+func TestNewAPIClientFromKubeConfig_UnknownContext(t *testing.T) {
+	client, err := newAPIClientFromKubeConfig("./testdata/kubeconfig.yml", "development")
+	assert.Nil(t, client)
+	assert.Error(t, err)
+}
+
+func TestNewAPIClientFromKubeConfig_AbsentFile(t *testing.T) {
+	client, err := newAPIClientFromKubeConfig("./testdata/__not_exist__", "")
+	assert.Nil(t, client)
+	assert.Error(t, err)
+}
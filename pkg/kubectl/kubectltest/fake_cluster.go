@@ -0,0 +1,313 @@
+// Package kubectltest provides in-memory kubectl.Cluster implementation for tests
+package kubectltest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/Dalee/fuse/pkg/kubectl"
+)
+
+type (
+	// FakeCluster is in-memory kubectl.Cluster implementation, intended to test command scenarios
+	FakeCluster struct {
+		// cluster state: deployments, pods, replica sets, etc.
+		Resources kubectl.ResourceList
+
+		// container logs, keyed by "namespace/pod/container"
+		LogList map[string]string
+
+		// live resource manifests, keyed by "kind/namespace/name"
+		ManifestList map[string]string
+
+		// optional hooks to simulate cluster behaviour
+		ApplyFunc func(c *FakeCluster, configurationYaml string) ([]byte, error)
+		UndoFunc  func(c *FakeCluster, namespace, kind, name string, toRevision int) ([]byte, error)
+
+		// optional dry-run hook, configuration file content is returned by default
+		DryRunFunc func(c *FakeCluster, configurationYaml, mode string) ([]byte, error)
+
+		// error returned by Watch, simulates cluster without watch support
+		WatchErr error
+
+		// events sent by Watch after current state, simulates changes during rollout
+		WatchEventList []kubectl.WatchEvent
+
+		// error returned by List calls, simulates unreachable cluster
+		ListErr error
+
+		// recorded calls
+		AppliedList  []string // configuration files
+		DryRunList   []string // "mode: configuration file"
+		UndoneList   []string // "kind/namespace/name@revision"
+		DeletedList  []string // "kind/namespace/name"
+		ExecutedList []string // "namespace/pod/container: command"
+		WatchedList  []string // "kind/namespace"
+	}
+
+	// fake stream, current state and registered events
+	fakeResourceWatch struct {
+		result chan kubectl.WatchEvent
+		once   sync.Once
+	}
+)
+
+// NewFakeCluster creates FakeCluster with given initial state
+func NewFakeCluster(resources ...kubectl.KubeResourceInterface) *FakeCluster {
+	return &FakeCluster{
+		Resources:    resources,
+		LogList:      make(map[string]string),
+		ManifestList: make(map[string]string),
+	}
+}
+
+// GetResource find resource by kind, namespace and name
+func (c *FakeCluster) GetResource(ctx context.Context, namespace, kind, name string) (kubectl.KubeResourceInterface, error) {
+	for _, r := range c.Resources {
+		if r.GetKind() == kind && r.GetName() == name && fakeNamespace(r) == formatNamespace(namespace) {
+			return r, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Error from server (NotFound): %s \"%s\" not found", kind, name)
+}
+
+// GetDeployment find deployment by namespace and name
+func (c *FakeCluster) GetDeployment(ctx context.Context, namespace, name string) (*kubectl.Deployment, error) {
+	r, err := c.GetResource(ctx, namespace, kubectl.KindDeployment, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ToDeployment()
+}
+
+// GetManifest return registered manifest of resource
+func (c *FakeCluster) GetManifest(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	manifest, ok := c.ManifestList[fmt.Sprintf("%s/%s/%s", kind, formatNamespace(namespace), name)]
+	if !ok {
+		return nil, fmt.Errorf("Error from server (NotFound): %s \"%s\" not found", kind, name)
+	}
+
+	return []byte(manifest), nil
+}
+
+// ListDeployments find deployments by selector
+func (c *FakeCluster) ListDeployments(ctx context.Context, namespace string, selector []string) ([]kubectl.Deployment, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.find(namespace, kubectl.KindDeployment, selector).ToDeploymentList(), nil
+}
+
+// ListPods find pods by selector
+func (c *FakeCluster) ListPods(ctx context.Context, namespace string, selector []string) ([]kubectl.Pod, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.find(namespace, kubectl.KindPod, selector).ToPodList(), nil
+}
+
+// ListReplicaSets find replica sets by selector
+func (c *FakeCluster) ListReplicaSets(ctx context.Context, namespace string, selector []string) ([]kubectl.ReplicaSet, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.find(namespace, kubectl.KindReplicaSet, selector).ToReplicaSetList(), nil
+}
+
+// ListControllerRevisions find controller revisions by selector
+func (c *FakeCluster) ListControllerRevisions(ctx context.Context, namespace string, selector []string) ([]kubectl.ControllerRevision, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.find(namespace, kubectl.KindControllerRevision, selector).ToControllerRevisionList(), nil
+}
+
+// ListResources find every resource of given kind
+func (c *FakeCluster) ListResources(ctx context.Context, namespace, kind string) (kubectl.ResourceList, error) {
+	if c.ListErr != nil {
+		return nil, c.ListErr
+	}
+	return c.find(namespace, kind, nil), nil
+}
+
+// Apply record configuration file and call ApplyFunc
+func (c *FakeCluster) Apply(ctx context.Context, configurationYaml string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.AppliedList = append(c.AppliedList, configurationYaml)
+	if c.ApplyFunc != nil {
+		return c.ApplyFunc(c, configurationYaml)
+	}
+
+	return []byte{}, nil
+}
+
+// ApplyDryRun record configuration file and call DryRunFunc
+func (c *FakeCluster) ApplyDryRun(ctx context.Context, configurationYaml, mode string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.DryRunList = append(c.DryRunList, fmt.Sprintf("%s: %s", mode, configurationYaml))
+	if c.DryRunFunc != nil {
+		return c.DryRunFunc(c, configurationYaml, mode)
+	}
+
+	return ioutil.ReadFile(configurationYaml)
+}
+
+// Undo record rollback and call UndoFunc
+func (c *FakeCluster) Undo(ctx context.Context, namespace, kind, name string, toRevision int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := c.GetResource(ctx, namespace, kind, name); err != nil {
+		return nil, err
+	}
+
+	c.UndoneList = append(c.UndoneList, fmt.Sprintf("%s/%s/%s@%d", kind, formatNamespace(namespace), name, toRevision))
+	if c.UndoFunc != nil {
+		return c.UndoFunc(c, namespace, kind, name, toRevision)
+	}
+
+	return []byte(fmt.Sprintf("%s \"%s\" rolled back", kind, name)), nil
+}
+
+// Delete remove resource from cluster state
+func (c *FakeCluster) Delete(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r, err := c.GetResource(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make(kubectl.ResourceList, 0)
+	for _, item := range c.Resources {
+		if item != r {
+			resources = append(resources, item)
+		}
+	}
+
+	c.Resources = resources
+	c.DeletedList = append(c.DeletedList, fmt.Sprintf("%s/%s/%s", kind, formatNamespace(namespace), name))
+	return []byte(fmt.Sprintf("%s \"%s\" deleted", kind, name)), nil
+}
+
+// Logs return registered logs of container
+func (c *FakeCluster) Logs(ctx context.Context, namespace, pod, container string) ([]byte, error) {
+	return []byte(c.LogList[fmt.Sprintf("%s/%s/%s", formatNamespace(namespace), pod, container)]), nil
+}
+
+// Exec record command execution
+func (c *FakeCluster) Exec(ctx context.Context, namespace, pod, container, command string) ([]byte, error) {
+	c.ExecutedList = append(c.ExecutedList, fmt.Sprintf("%s/%s/%s: %s", formatNamespace(namespace), pod, container, command))
+	return []byte{}, nil
+}
+
+// Watch send current state of resources of kind followed by registered events of kind,
+// stream is never interrupted
+func (c *FakeCluster) Watch(ctx context.Context, namespace, kind string) (kubectl.ResourceWatch, error) {
+	if c.WatchErr != nil {
+		return nil, c.WatchErr
+	}
+
+	c.WatchedList = append(c.WatchedList, fmt.Sprintf("%s/%s", kind, formatNamespace(namespace)))
+	eventList := make([]kubectl.WatchEvent, 0)
+	for _, r := range c.find(namespace, kind, nil) {
+		eventList = append(eventList, kubectl.WatchEvent{Type: kubectl.WatchAdded, Resource: r})
+	}
+	for _, event := range c.WatchEventList {
+		isNamespaceMatched := namespace == kubectl.AllNamespaces || fakeNamespace(event.Resource) == formatNamespace(namespace)
+		if event.Resource.GetKind() == kind && isNamespaceMatched {
+			eventList = append(eventList, event)
+		}
+	}
+
+	w := &fakeResourceWatch{
+		result: make(chan kubectl.WatchEvent, len(eventList)),
+	}
+	for _, event := range eventList {
+		w.result <- event
+	}
+
+	return w, nil
+}
+
+// ResultChan return channel of resource changes
+func (w *fakeResourceWatch) ResultChan() <-chan kubectl.WatchEvent {
+	return w.result
+}
+
+// Stop close channel, until then stream is kept open
+func (w *fakeResourceWatch) Stop() {
+	w.once.Do(func() {
+		close(w.result)
+	})
+}
+
+// find resources of kind in namespace matching selector
+func (c *FakeCluster) find(namespace, kind string, selector []string) kubectl.ResourceList {
+	result := make(kubectl.ResourceList, 0)
+	for _, r := range c.Resources {
+		isNamespaceMatched := namespace == kubectl.AllNamespaces || fakeNamespace(r) == formatNamespace(namespace)
+		if r.GetKind() == kind && isNamespaceMatched && kubectl.MatchSelector(fakeLabels(r), selector) {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// namespace and labels of resource, not every resource type exposes them
+func fakeMetadata(r kubectl.KubeResourceInterface) (string, map[string]string) {
+	switch o := r.(type) {
+	case *kubectl.Deployment:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.StatefulSet:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.DaemonSet:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.Job:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.CronJob:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.ReplicaSet:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.ControllerRevision:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.Pod:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.Namespace:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	case *kubectl.Resource:
+		return o.Metadata.Namespace, o.Metadata.Labels
+	}
+	return "", nil
+}
+
+func fakeNamespace(r kubectl.KubeResourceInterface) string {
+	namespace, _ := fakeMetadata(r)
+	return formatNamespace(namespace)
+}
+
+func fakeLabels(r kubectl.KubeResourceInterface) map[string]string {
+	_, labels := fakeMetadata(r)
+	return labels
+}
+
+// empty namespace is the default one, as for kubectl
+func formatNamespace(namespace string) string {
+	if namespace == "" {
+		return "default"
+	}
+	return namespace
+}
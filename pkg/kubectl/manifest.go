@@ -0,0 +1,289 @@
+package kubectl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// AnnotationLastApplied is annotation kubectl apply stores applied configuration in
+	AnnotationLastApplied = "kubectl.kubernetes.io/last-applied-configuration"
+
+	// secret kind
+	kindSecret = "secret"
+)
+
+var (
+	// metadata fields populated by server
+	serverMetadataFieldList = []string{"uid", "resourceVersion", "creationTimestamp", "generation", "selfLink", "managedFields"}
+
+	// annotations populated by server or kubectl
+	serverAnnotationList = []string{AnnotationLastApplied, AnnotationRevision}
+
+	// pod spec location in Pod, workloads with pod template and CronJob
+	podSpecPathList = [][]string{
+		{"spec"},
+		{"spec", "template", "spec"},
+		{"spec", "jobTemplate", "spec", "template", "spec"},
+	}
+)
+
+// remove fields populated by server, otherwise object can't be applied again
+func cleanManifest(data []byte) ([]byte, error) {
+	object := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	delete(object, "status")
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		for _, field := range serverMetadataFieldList {
+			delete(metadata, field)
+		}
+	}
+
+	return yaml.Marshal(object)
+}
+
+// PrepareDiff normalize live and local manifests of resource to be compared line by line,
+// server populated fields and fields defaulted by server are removed, secret values are replaced
+// with hashes, empty manifest means missing resource. Field is defaulted by server, if it is absent
+// both in local manifest and in last applied configuration of live resource, so fields removed
+// from local manifest are kept and shown as removed.
+func PrepareDiff(live, local []byte) ([]byte, []byte, error) {
+	appliedObject, err := decodeLastApplied(live)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	liveObject, err := decodeDiffObject(live)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localObject, err := decodeDiffObject(local)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if liveObject != nil && localObject != nil {
+		liveObject = pruneDefaulted(liveObject, localObject, appliedObject).(map[string]interface{})
+	}
+
+	liveData, err := encodeDiffObject(liveObject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localData, err := encodeDiffObject(localObject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return liveData, localData, nil
+}
+
+// decode manifest and remove everything which should not be compared
+func decodeDiffObject(data []byte) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	object := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	delete(object, "status")
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		for _, field := range serverMetadataFieldList {
+			delete(metadata, field)
+		}
+
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for _, annotation := range serverAnnotationList {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+
+	kind, _ := object["kind"].(string)
+	if strings.ToLower(kind) == kindSecret {
+		hideSecretData(object)
+	}
+
+	return object, nil
+}
+
+// configuration recorded by last apply, nil if resource is missing or was not applied
+func decodeLastApplied(data []byte) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	object := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	metadata, _ := object["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	lastApplied, _ := annotations[AnnotationLastApplied].(string)
+	if lastApplied == "" {
+		return nil, nil
+	}
+
+	applied := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(lastApplied), &applied); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %s", AnnotationLastApplied, err)
+	}
+
+	// secret stringData is stored by server as data
+	kind, _ := applied["kind"].(string)
+	if strings.ToLower(kind) == kindSecret {
+		hideSecretData(applied)
+	}
+
+	return applied, nil
+}
+
+func encodeDiffObject(object map[string]interface{}) ([]byte, error) {
+	if object == nil {
+		return []byte{}, nil
+	}
+	return yaml.Marshal(object)
+}
+
+// secret values should never be printed, stringData is converted to data
+// to be comparable with live secret, every value is replaced with hash
+func hideSecretData(object map[string]interface{}) {
+	data, _ := object["data"].(map[string]interface{})
+	if stringData, ok := object["stringData"].(map[string]interface{}); ok {
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		for key, value := range stringData {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+		}
+		delete(object, "stringData")
+	}
+
+	for key, value := range data {
+		sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+		data[key] = fmt.Sprintf("(sha256:%x)", sum[:6])
+	}
+	if data != nil {
+		object["data"] = data
+	}
+}
+
+// keep only fields of live object defined in local object or in last applied configuration,
+// other fields are defaulted by server, extra list items are kept, as they are removed by apply
+func pruneDefaulted(live, local, applied interface{}) interface{} {
+	switch liveValue := live.(type) {
+	case map[string]interface{}:
+		localMap, isLocalMap := local.(map[string]interface{})
+		appliedMap, isAppliedMap := applied.(map[string]interface{})
+		if !isLocalMap && !isAppliedMap {
+			return live
+		}
+
+		result := make(map[string]interface{})
+		for key, value := range liveValue {
+			localItem, isLocal := localMap[key]
+			appliedItem, isApplied := appliedMap[key]
+			if isLocal || isApplied {
+				result[key] = pruneDefaulted(value, localItem, appliedItem)
+			}
+		}
+		return result
+
+	case []interface{}:
+		localList, isLocalList := local.([]interface{})
+		appliedList, isAppliedList := applied.([]interface{})
+		if !isLocalList && !isAppliedList {
+			return live
+		}
+
+		result := make([]interface{}, len(liveValue))
+		for i := range liveValue {
+			result[i] = liveValue[i]
+
+			var localItem, appliedItem interface{}
+			if i < len(localList) {
+				localItem = localList[i]
+			}
+			if i < len(appliedList) {
+				appliedItem = appliedList[i]
+			}
+			if localItem != nil || appliedItem != nil {
+				result[i] = pruneDefaulted(liveValue[i], localItem, appliedItem)
+			}
+		}
+		return result
+	}
+
+	return live
+}
+
+// ReplaceImages replace images of containers and init containers by imageMap,
+// resources without replaced images are returned as is
+func ReplaceImages(resourceList []Resource, imageMap map[string]string) ([]Resource, error) {
+	replacedList := make([]Resource, 0)
+	for _, r := range resourceList {
+		object := make(map[string]interface{})
+		if err := yaml.Unmarshal(r.Manifest, &object); err != nil {
+			return nil, err
+		}
+
+		isReplaced := false
+		for _, path := range podSpecPathList {
+			podSpec := lookupObject(object, path)
+			for _, key := range []string{"initContainers", "containers"} {
+				containerList, _ := podSpec[key].([]interface{})
+				for _, item := range containerList {
+					container, ok := item.(map[string]interface{})
+					if !ok {
+						continue
+					}
+
+					image, _ := container["image"].(string)
+					if replacement, ok := imageMap[image]; ok {
+						container["image"] = replacement
+						isReplaced = true
+					}
+				}
+			}
+		}
+
+		if isReplaced {
+			manifest, err := yaml.Marshal(object)
+			if err != nil {
+				return nil, err
+			}
+			r.Manifest = manifest
+		}
+		replacedList = append(replacedList, r)
+	}
+
+	return replacedList, nil
+}
+
+// nested object by path of keys, nil if path doesn't exist
+func lookupObject(object map[string]interface{}, path []string) map[string]interface{} {
+	for _, key := range path {
+		next, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = next
+	}
+	return object
+}
@@ -0,0 +1,201 @@
+package kubectl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCleanManifest(t *testing.T) {
+	data, err := cleanManifest([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backend-config
+  namespace: default
+  uid: 7f3b1c4e
+  resourceVersion: "1234"
+  creationTimestamp: "2017-07-04T15:49:11Z"
+data:
+  APP_ENV: production
+status: {}
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: v1
+data:
+  APP_ENV: production
+kind: ConfigMap
+metadata:
+  name: backend-config
+  namespace: default
+`, string(data))
+}
+
+func TestPrepareDiff(t *testing.T) {
+	live := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: default
+  uid: 7f3b1c4e
+  resourceVersion: "1234"
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+spec:
+  clusterIP: 10.0.0.12
+  type: ClusterIP
+  ports:
+  - port: 80
+    protocol: TCP
+    targetPort: 80
+  - port: 443
+    protocol: TCP
+status:
+  loadBalancer: {}
+`)
+	local := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: default
+spec:
+  ports:
+  - port: 8080
+`)
+
+	liveData, localData, err := PrepareDiff(live, local)
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: default
+spec:
+  ports:
+  - port: 80
+  - port: 443
+    protocol: TCP
+`, string(liveData))
+	assert.Equal(t, string(local), string(localData))
+}
+
+func TestPrepareDiff_RemovedDataKey(t *testing.T) {
+	live := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: backend-config
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"backend-config"},"data":{"APP_DEBUG":"1","APP_ENV":"production"}}'
+data:
+  APP_DEBUG: "1"
+  APP_ENV: production
+`)
+	local := []byte(`apiVersion: v1
+data:
+  APP_ENV: production
+kind: ConfigMap
+metadata:
+  name: backend-config
+`)
+
+	liveData, localData, err := PrepareDiff(live, local)
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: v1
+data:
+  APP_DEBUG: "1"
+  APP_ENV: production
+kind: ConfigMap
+metadata:
+  name: backend-config
+`, string(liveData))
+	assert.Equal(t, string(local), string(localData))
+}
+
+func TestPrepareDiff_RemovedLabel(t *testing.T) {
+	live := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: backend
+  labels:
+    app: backend
+    tier: web
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"backend","tier":"web"},"name":"backend"},"spec":{"replicas":2}}'
+spec:
+  replicas: 2
+  revisionHistoryLimit: 10
+`)
+	local := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: backend
+  name: backend
+spec:
+  replicas: 2
+`)
+
+	liveData, localData, err := PrepareDiff(live, local)
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: backend
+    tier: web
+  name: backend
+spec:
+  replicas: 2
+`, string(liveData))
+	assert.Equal(t, string(local), string(localData))
+}
+
+func TestPrepareDiff_NewResource(t *testing.T) {
+	liveData, localData, err := PrepareDiff(nil, []byte("kind: ConfigMap\nmetadata:\n  name: backend\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(liveData))
+	assert.Equal(t, "kind: ConfigMap\nmetadata:\n  name: backend\n", string(localData))
+}
+
+func TestPrepareDiff_Secret(t *testing.T) {
+	live := []byte("kind: Secret\nmetadata:\n  name: backend\ndata:\n  password: c2VjcmV0\n")
+	local := []byte("kind: Secret\nmetadata:\n  name: backend\nstringData:\n  password: secret\n")
+
+	liveData, localData, err := PrepareDiff(live, local)
+	assert.Nil(t, err)
+	assert.Equal(t, string(liveData), string(localData))
+	assert.NotContains(t, string(localData), "secret\n")
+	assert.Contains(t, string(localData), "password: (sha256:")
+}
+
+func TestReplaceImages(t *testing.T) {
+	resourceList, err := ParseResources([]byte(`kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: backend:1
+      containers:
+      - name: backend
+        image: backend:1
+      - name: sidecar
+        image: sidecar:1
+---
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: backend:1
+`))
+	assert.Nil(t, err)
+
+	replacedList, err := ReplaceImages(resourceList, map[string]string{"backend:1": "backend:1@sha256:ffff"})
+	assert.Nil(t, err)
+	assert.Len(t, replacedList, 2)
+
+	images, err := replacedList[0].GetImages()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"backend:1@sha256:ffff", "backend:1@sha256:ffff", "sidecar:1"}, images)
+	assert.Equal(t, resourceList[1].Manifest, replacedList[1].Manifest)
+}
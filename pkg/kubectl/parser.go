@@ -33,6 +33,80 @@ func ParseLocalFile(filename string) (ResourceList, error) {
 	return newParser().parseYaml(data)
 }
 
+// ParseLocalFileResources will parse every resource defined in local file, regardless of kind
+func ParseLocalFileResources(filename string) ([]Resource, error) {
+	file, _ := filepath.Abs(filename)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseResources(data)
+}
+
+// ParseResources parse multi-document yaml, items of List documents are returned as separate resources
+func ParseResources(data []byte) ([]Resource, error) {
+	resourceList := make([]Resource, 0)
+	maxBufferSize := 1024 * 1024 * 200 // should be enough
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBufferSize)
+	scanner.Split(splitYAMLDocument)
+
+	for scanner.Scan() {
+		documentList, err := splitListDocument(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, document := range documentList {
+			item := &kubeResource{}
+			if err := yaml.Unmarshal(document, item); err != nil {
+				return nil, err
+			}
+
+			if item.Kind != "" && item.Metadata.Name != "" {
+				resourceList = append(resourceList, Resource{Kind: item.Kind, Metadata: item.Metadata, Manifest: document})
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return resourceList, nil
+}
+
+// split document of kind List into separate documents, one per item
+func splitListDocument(data []byte) ([][]byte, error) {
+	resource := &kubeResource{}
+	if err := yaml.Unmarshal(data, resource); err != nil {
+		return nil, err
+	}
+	if resource.GetKind() != KindList {
+		return [][]byte{data}, nil
+	}
+
+	listObject := &struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	if err := yaml.Unmarshal(data, listObject); err != nil {
+		return nil, err
+	}
+
+	documentList := make([][]byte, 0)
+	for _, item := range listObject.Items {
+		document, err := yaml.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		documentList = append(documentList, document)
+	}
+
+	return documentList, nil
+}
+
 // parse whole kubectl answer into list of objects
 func (p *kubeResourceParser) parseYaml(data []byte) (ResourceList, error) {
 	typeList := make(ResourceList, 0)
@@ -97,9 +171,29 @@ func parseKubeResource(data []byte, resource KubeResourceInterface) (ResourceLis
 				resourceList = &deploymentList{}
 				break
 
+			case KindStatefulSet:
+				resourceList = &statefulSetList{}
+				break
+
+			case KindDaemonSet:
+				resourceList = &daemonSetList{}
+				break
+
+			case KindJob:
+				resourceList = &jobList{}
+				break
+
+			case KindCronJob:
+				resourceList = &cronJobList{}
+				break
+
 			case KindReplicaSet:
 				resourceList = &replicaSetList{}
 				break
+
+			case KindControllerRevision:
+				resourceList = &controllerRevisionList{}
+				break
 			}
 
 			if resourceList != nil {
@@ -119,10 +213,30 @@ func parseKubeResource(data []byte, resource KubeResourceInterface) (ResourceLis
 		object = &Deployment{}
 		break
 
+	case KindStatefulSet: // parse statefulset object
+		object = &StatefulSet{}
+		break
+
+	case KindDaemonSet: // parse daemonset object
+		object = &DaemonSet{}
+		break
+
+	case KindJob: // parse job object
+		object = &Job{}
+		break
+
+	case KindCronJob: // parse cronjob object
+		object = &CronJob{}
+		break
+
 	case KindReplicaSet: // parse replicaset object
 		object = &ReplicaSet{}
 		break
 
+	case KindControllerRevision: // parse controllerrevision object
+		object = &ControllerRevision{}
+		break
+
 	case KindNamespace: // parse namespace object
 		object = &Namespace{}
 		break
@@ -81,6 +81,85 @@ spec:
 	assert.Equal(t, d.Metadata.Generation, 42)
 }
 
+// ensure deployment conditions are parsed
+func TestParseDeploymentConditions(t *testing.T) {
+	rawYamlString := `---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: test-deployment
+  generation: 3
+spec:
+  replicas: 4
+  progressDeadlineSeconds: 120
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 1
+      maxUnavailable: 25%
+status:
+  observedGeneration: 3
+  conditions:
+  - type: Available
+    status: "True"
+    reason: MinimumReplicasAvailable
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+    message: ReplicaSet "test-deployment-2" has timed out progressing.
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+
+	dlist := result.ToDeploymentList()
+	assert.Len(t, dlist, 1)
+
+	d := dlist[0]
+	assert.Equal(t, 120, d.Spec.ProgressDeadlineSeconds)
+	assert.Equal(t, IntOrStringFromInt(1), d.Spec.Strategy.RollingUpdate.MaxSurge)
+	assert.Equal(t, IntOrStringFromString("25%"), d.Spec.Strategy.RollingUpdate.MaxUnavailable)
+	assert.Len(t, d.Status.Conditions, 2)
+	assert.Equal(t, "MinimumReplicasAvailable", d.GetCondition("Available").Reason)
+	assert.True(t, d.IsFailed())
+}
+
+// ensure deployment selector is parsed
+func TestParseDeploymentSelector(t *testing.T) {
+	rawYamlString := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-deployment
+  labels:
+    app: test-deployment
+    team: backend
+spec:
+  selector:
+    matchLabels:
+      app: test
+    matchExpressions:
+    - key: tier
+      operator: In
+      values: [web, api]
+  template:
+    metadata:
+      labels:
+        app: test
+        tier: web
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+
+	dlist := result.ToDeploymentList()
+	assert.Len(t, dlist, 1)
+
+	d := dlist[0]
+	assert.Equal(t, []string{"app=test", "tier in (web,api)"}, d.GetSelector())
+	assert.Equal(t, []string{"app=test", "tier in (web,api)"}, d.GetPodSelector())
+}
+
 // ensure parse replicaset works as expected
 func TestParseReplicaSet(t *testing.T) {
 	rawYamlString := `---
@@ -114,6 +193,159 @@ spec:
 	assert.Equal(t, "example.com/image:1", container.Image)
 }
 
+// ensure parse statefulset works as expected
+func TestParseStatefulSet(t *testing.T) {
+	rawYamlString := `---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: test-statefulset
+  namespace: default
+  generation: 2
+spec:
+  replicas: 3
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      partition: 1
+  template:
+    metadata:
+      labels:
+        app: "test"
+    spec:
+      containers:
+      - image: example.com/image:1
+status:
+  observedGeneration: 2
+  readyReplicas: 3
+  updatedReplicas: 2
+  currentRevision: test-statefulset-1
+  updateRevision: test-statefulset-2
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	slist := result.ToStatefulSetList()
+	assert.Len(t, slist, 1)
+
+	s := slist[0]
+	assert.Equal(t, KindStatefulSet, s.GetKind())
+	assert.Equal(t, "test-statefulset", s.GetName())
+	assert.Equal(t, 3, s.Spec.Replicas)
+	assert.Equal(t, 1, s.Spec.UpdateStrategy.RollingUpdate.Partition)
+	assert.Equal(t, "test-statefulset-1", s.Status.CurrentRevision)
+	assert.Equal(t, "test-statefulset-2", s.Status.UpdateRevision)
+	assert.Equal(t, []string{"app=test"}, s.GetPodSelector())
+	assert.True(t, s.IsReady())
+}
+
+// ensure parse list with daemonset items works as expected
+func TestParseDaemonSetList(t *testing.T) {
+	rawYamlString := `---
+apiVersion: v1
+items:
+- apiVersion: apps/v1
+  kind: DaemonSet
+  metadata:
+    name: test-daemonset
+    namespace: kube-system
+    generation: 7
+  spec:
+    updateStrategy:
+      type: RollingUpdate
+  status:
+    observedGeneration: 7
+    desiredNumberScheduled: 4
+    updatedNumberScheduled: 4
+    numberReady: 3
+    numberUnavailable: 1
+kind: List
+metadata: {}
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	dlist := result.ToDaemonSetList()
+	assert.Len(t, dlist, 1)
+
+	d := dlist[0]
+	assert.Equal(t, KindDaemonSet, d.GetKind())
+	assert.Equal(t, "kube-system/test-daemonset", d.GetKey())
+	assert.Equal(t, 4, d.Status.DesiredNumberScheduled)
+	assert.Equal(t, 3, d.Status.NumberReady)
+	assert.False(t, d.IsReady())
+}
+
+// ensure parse job works as expected
+func TestParseJob(t *testing.T) {
+	rawYamlString := `---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: db-migrate
+  namespace: default
+spec:
+  completions: 1
+  template:
+    spec:
+      containers:
+      - image: example.com/image:1
+      restartPolicy: Never
+status:
+  failed: 1
+  conditions:
+  - type: Failed
+    status: "True"
+    reason: BackoffLimitExceeded
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+
+	jlist := result.ToJobList()
+	assert.Len(t, jlist, 1)
+
+	j := jlist[0]
+	assert.Equal(t, KindJob, j.GetKind())
+	assert.Equal(t, "db-migrate", j.GetName())
+	assert.Equal(t, 1, j.Status.Failed)
+	assert.Len(t, j.Status.Conditions, 1)
+	assert.Equal(t, "BackoffLimitExceeded", j.Status.Conditions[0].Reason)
+	assert.True(t, j.IsFailed())
+	assert.Len(t, result.ToRolloutList(), 1)
+}
+
+// ensure parse list with controllerrevision items works as expected
+func TestParseControllerRevisionList(t *testing.T) {
+	rawYamlString := `---
+apiVersion: v1
+items:
+- apiVersion: apps/v1
+  kind: ControllerRevision
+  metadata:
+    name: test-statefulset-1
+  revision: 1
+- apiVersion: apps/v1
+  kind: ControllerRevision
+  metadata:
+    name: test-statefulset-2
+  revision: 2
+kind: List
+metadata: {}
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, KindControllerRevision, result[1].GetKind())
+	assert.Equal(t, "test-statefulset-2", result[1].GetName())
+}
+
 // ensure namespace parsing is ok
 func TestParseNamespace(t *testing.T) {
 	rawYamlString := `---
@@ -274,6 +506,52 @@ spec:
 	assert.Equal(t, pod.Metadata.Labels["app"], "test-label")
 }
 
+// ensure pod container statuses and conditions are parsed
+func TestParsePodStatus(t *testing.T) {
+	rawYamlString := `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod-1567981747-4awvj
+  namespace: default
+  labels:
+    pod-template-hash: "1567981747"
+spec:
+  containers:
+    - image: example.com/image:1
+      name: app
+status:
+  phase: Running
+  conditions:
+  - type: PodScheduled
+    status: "True"
+  containerStatuses:
+  - name: app
+    ready: false
+    restartCount: 4
+    state:
+      waiting:
+        reason: CrashLoopBackOff
+        message: Back-off 40s restarting failed container
+`
+	p := newParser()
+	result, err := p.parseYaml([]byte(rawYamlString))
+	assert.Nil(t, err)
+
+	plist := result.ToPodList()
+	assert.Len(t, plist, 1)
+
+	pod := plist[0]
+	assert.Len(t, pod.Status.Conditions, 1)
+	assert.Len(t, pod.Status.ContainerStatuses, 1)
+
+	cs := pod.Status.ContainerStatuses[0]
+	assert.Equal(t, "app", cs.Name)
+	assert.Equal(t, 4, cs.RestartCount)
+	assert.Equal(t, "CrashLoopBackOff", cs.State.Waiting.Reason)
+	assert.Equal(t, "container app: CrashLoopBackOff", pod.GetFailureReason(10))
+}
+
 // ensure parse list with namespace items works as expected
 func TestParseDeploymentList(t *testing.T) {
 	rawYamlString := `apiVersion: v1
@@ -424,3 +702,36 @@ func TestParseLocalFile_AbsFailed(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Error(t, err)
 }
+
+// ensure every resource is parsed, regardless of kind
+func TestParseLocalFileResources(t *testing.T) {
+	rlist, err := ParseLocalFileResources("testdata/parser_test2.yml")
+	assert.Nil(t, err)
+	assert.Len(t, rlist, 3)
+
+	assert.Equal(t, "configmap", rlist[0].GetKind())
+	assert.Equal(t, "ConfigMap", rlist[0].Kind)
+	assert.Equal(t, "default/backend-config", rlist[0].GetKey())
+
+	assert.Equal(t, "service", rlist[1].GetKind())
+	assert.Equal(t, "kube-system/backend", rlist[1].GetKey())
+
+	assert.Equal(t, KindDeployment, rlist[2].GetKind())
+	assert.Equal(t, "backend", rlist[2].GetName())
+}
+
+func TestParseLocalFileResources_Missing(t *testing.T) {
+	_, err := ParseLocalFileResources("testdata/missing.yml")
+	assert.NotNil(t, err)
+}
+
+// ensure items of List are parsed as separate resources
+func TestParseLocalFileResources_List(t *testing.T) {
+	rlist, err := ParseLocalFileResources("testdata/parser_test3.yml")
+	assert.Nil(t, err)
+	assert.Len(t, rlist, 2)
+
+	assert.Equal(t, "secret", rlist[0].GetKind())
+	assert.Equal(t, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: backend-secret\n", string(rlist[0].Manifest))
+	assert.Equal(t, "ingress", rlist[1].GetKind())
+}
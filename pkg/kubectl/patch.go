@@ -0,0 +1,215 @@
+package kubectl
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// PatchTypeStrategic merges lists of containers, env, volumes, ports, etc. by their merge keys,
+	// as Kubernetes does, items and maps can be removed with "$patch: delete" directive
+	PatchTypeStrategic = "strategic"
+
+	// PatchTypeMerge is JSON merge patch (RFC 7386), lists are replaced as whole
+	PatchTypeMerge = "merge"
+
+	// strategic merge patch directive key
+	patchDirective = "$patch"
+)
+
+var (
+	// merge keys of lists by field name, the first key defined in every item is used
+	// (container ports are merged by containerPort, service ports by port),
+	// other lists are replaced in strategic mode
+	patchMergeKeyMap = map[string][]string{
+		"containers":          {"name"},
+		"initContainers":      {"name"},
+		"ephemeralContainers": {"name"},
+		"env":                 {"name"},
+		"volumes":             {"name"},
+		"imagePullSecrets":    {"name"},
+		"volumeMounts":        {"mountPath"},
+		"volumeDevices":       {"devicePath"},
+		"ports":               {"containerPort", "port"},
+		"hostAliases":         {"ip"},
+	}
+)
+
+// ApplyPatches apply every patch to resource with the same kind, namespace and name,
+// patch without matching resource is an error
+func ApplyPatches(resourceList []Resource, patchList []Resource, patchType string) ([]Resource, error) {
+	if patchType != PatchTypeStrategic && patchType != PatchTypeMerge {
+		return nil, fmt.Errorf("unknown patch type: %s", patchType)
+	}
+
+	patchedList := make([]Resource, len(resourceList))
+	copy(patchedList, resourceList)
+
+	for _, patch := range patchList {
+		index := -1
+		for i, r := range patchedList {
+			if r.GetKind() == patch.GetKind() && r.GetKey() == patch.GetKey() {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("patch target %s/%s not found in configuration", patch.GetKind(), patch.GetKey())
+		}
+
+		patched, err := patchResource(patchedList[index], patch, patchType == PatchTypeStrategic)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %s", patch.GetKind(), patch.GetKey(), err)
+		}
+		patchedList[index] = patched
+	}
+
+	return patchedList, nil
+}
+
+// JoinResources build multi-document yaml from resource manifests
+func JoinResources(resourceList []Resource) []byte {
+	buf := &bytes.Buffer{}
+	for _, r := range resourceList {
+		appendDocument(buf, r.Manifest)
+	}
+	return buf.Bytes()
+}
+
+// apply single patch to resource, metadata is updated from patched manifest
+func patchResource(r Resource, patch Resource, strategic bool) (Resource, error) {
+	original := make(map[string]interface{})
+	if err := yaml.Unmarshal(r.Manifest, &original); err != nil {
+		return r, err
+	}
+	patchObject := make(map[string]interface{})
+	if err := yaml.Unmarshal(patch.Manifest, &patchObject); err != nil {
+		return r, err
+	}
+
+	manifest, err := yaml.Marshal(mergeObject(original, patchObject, strategic))
+	if err != nil {
+		return r, err
+	}
+
+	patched := Resource{}
+	if err := yaml.Unmarshal(manifest, &patched); err != nil {
+		return r, err
+	}
+	patched.Manifest = manifest
+
+	return patched, nil
+}
+
+// merge patch into original object, null value removes key
+func mergeObject(original, patch map[string]interface{}, strategic bool) map[string]interface{} {
+	if strategic && patch[patchDirective] == "replace" {
+		return withoutDirective(patch)
+	}
+
+	for key, value := range patch {
+		if strategic && key == patchDirective {
+			continue
+		}
+
+		switch patchValue := value.(type) {
+		case nil:
+			delete(original, key)
+
+		case map[string]interface{}:
+			if strategic && patchValue[patchDirective] == "delete" {
+				delete(original, key)
+				continue
+			}
+
+			if originalValue, ok := original[key].(map[string]interface{}); ok {
+				original[key] = mergeObject(originalValue, patchValue, strategic)
+			} else {
+				original[key] = mergeObject(make(map[string]interface{}), patchValue, strategic)
+			}
+
+		case []interface{}:
+			originalValue, ok := original[key].([]interface{})
+			if !strategic || !ok {
+				original[key] = patchValue
+				continue
+			}
+
+			if mergeKey, ok := findMergeKey(key, originalValue, patchValue); ok {
+				original[key] = mergeList(originalValue, patchValue, mergeKey)
+			} else {
+				original[key] = patchValue
+			}
+
+		default:
+			original[key] = value
+		}
+	}
+
+	return original
+}
+
+// merge list items by merge key, new items are appended
+func mergeList(original, patch []interface{}, mergeKey string) []interface{} {
+	merged := make([]interface{}, len(original))
+	copy(merged, original)
+
+	for _, item := range patch {
+		patchItem := item.(map[string]interface{})
+		index := -1
+		for i, originalItem := range merged {
+			if originalItem.(map[string]interface{})[mergeKey] == patchItem[mergeKey] {
+				index = i
+				break
+			}
+		}
+
+		switch {
+		case patchItem[patchDirective] == "delete":
+			if index >= 0 {
+				merged = append(merged[:index], merged[index+1:]...)
+			}
+		case index >= 0:
+			merged[index] = mergeObject(merged[index].(map[string]interface{}), patchItem, true)
+		default:
+			merged = append(merged, withoutDirective(patchItem))
+		}
+	}
+
+	return merged
+}
+
+// merge key of list field, every item of both lists must be a map with merge key
+func findMergeKey(field string, original, patch []interface{}) (string, bool) {
+	for _, mergeKey := range patchMergeKeyMap[field] {
+		if hasMergeKey(original, mergeKey) && hasMergeKey(patch, mergeKey) {
+			return mergeKey, true
+		}
+	}
+	return "", false
+}
+
+func hasMergeKey(list []interface{}, mergeKey string) bool {
+	for _, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok := object[mergeKey]; !ok || value == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// copy of object without strategic merge directive
+func withoutDirective(object map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range object {
+		if key != patchDirective {
+			result[key] = value
+		}
+	}
+	return result
+}
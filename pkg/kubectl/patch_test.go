@@ -0,0 +1,164 @@
+package kubectl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func loadPatchTestResources(t *testing.T, filename string) []Resource {
+	resourceList, err := ParseLocalFileResources(filename)
+	assert.Nil(t, err)
+	return resourceList
+}
+
+func TestApplyPatches_Strategic(t *testing.T) {
+	resourceList := loadPatchTestResources(t, "testdata/patch_base.yml")
+	patchList := loadPatchTestResources(t, "testdata/patch_production.yml")
+
+	patchedList, err := ApplyPatches(resourceList, patchList, PatchTypeStrategic)
+	assert.Nil(t, err)
+	assert.Len(t, patchedList, 2)
+	assert.Equal(t, map[string]string{"app": "backend", "tier": "web"}, patchedList[0].Metadata.Labels)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: backend
+    tier: web
+  name: backend
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - env:
+        - name: APP_ENV
+          value: production
+        image: backend:2
+        name: backend
+      - image: sidecar:1
+        name: sidecar
+      - image: metrics:1
+        name: metrics
+`, string(patchedList[0].Manifest))
+
+	// original resources are not modified, other resources are left as is
+	assert.Contains(t, string(resourceList[0].Manifest), "replicas: 1")
+	assert.Equal(t, resourceList[1].Manifest, patchedList[1].Manifest)
+}
+
+func TestApplyPatches_StrategicMergeKeys(t *testing.T) {
+	resourceList, err := ParseResources([]byte(`kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      containers:
+      - name: backend
+        ports:
+        - containerPort: 8080
+        - containerPort: 9090
+          protocol: UDP
+        volumeMounts:
+        - mountPath: /etc/backend
+          name: config
+        - mountPath: /var/cache
+          name: cache
+`))
+	assert.Nil(t, err)
+
+	// mounts are merged by mountPath, unnamed ports by containerPort
+	patchList, err := ParseResources([]byte(`kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      containers:
+      - name: backend
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+        - containerPort: 8443
+        volumeMounts:
+        - mountPath: /etc/backend
+          readOnly: true
+        - $patch: delete
+          mountPath: /var/cache
+`))
+	assert.Nil(t, err)
+
+	patchedList, err := ApplyPatches(resourceList, patchList, PatchTypeStrategic)
+	assert.Nil(t, err)
+	assert.Equal(t, `kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      containers:
+      - name: backend
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+        - containerPort: 9090
+          protocol: UDP
+        - containerPort: 8443
+        volumeMounts:
+        - mountPath: /etc/backend
+          name: config
+          readOnly: true
+`, string(patchedList[0].Manifest))
+}
+
+func TestApplyPatches_Merge(t *testing.T) {
+	resourceList := loadPatchTestResources(t, "testdata/patch_base.yml")
+	patchList, err := ParseResources([]byte(`kind: Service
+metadata:
+  name: backend
+  namespace: production
+spec:
+  ports:
+  - port: 443
+  type: LoadBalancer
+`))
+	assert.Nil(t, err)
+
+	patchedList, err := ApplyPatches(resourceList, patchList, PatchTypeMerge)
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: production
+spec:
+  ports:
+  - port: 443
+  type: LoadBalancer
+`, string(patchedList[1].Manifest))
+}
+
+func TestApplyPatches_Errors(t *testing.T) {
+	resourceList := loadPatchTestResources(t, "testdata/patch_base.yml")
+
+	// service is defined in production namespace only
+	patchList, err := ParseResources([]byte("kind: Service\nmetadata:\n  name: backend\n"))
+	assert.Nil(t, err)
+
+	_, err = ApplyPatches(resourceList, patchList, PatchTypeStrategic)
+	assert.EqualError(t, err, "patch target service/default/backend not found in configuration")
+
+	_, err = ApplyPatches(resourceList, nil, "json")
+	assert.NotNil(t, err)
+}
+
+func TestJoinResources(t *testing.T) {
+	resourceList, err := ParseResources([]byte("kind: ConfigMap\nmetadata:\n  name: a\n---\nkind: ConfigMap\nmetadata:\n  name: b\n"))
+	assert.Nil(t, err)
+
+	joined, err := ParseResources(JoinResources(resourceList))
+	assert.Nil(t, err)
+	assert.Len(t, joined, 2)
+	assert.Equal(t, "b", joined[1].GetName())
+}
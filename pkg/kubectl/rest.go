@@ -0,0 +1,849 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// BackendKubectl is the name of backend which runs kubectl binary (default)
+	BackendKubectl = "kubectl"
+
+	// BackendAPI is the name of backend which talks to Kubernetes API server directly
+	BackendAPI = "api"
+
+	// ClusterBackendEnv is the name of environment variable to select cluster backend
+	ClusterBackendEnv = "CLUSTER_BACKEND"
+
+	// field manager name of every change made by fuse, server records other changes
+	// under manager derived from User-Agent, so it's set too
+	apiFieldManager = "fuse"
+
+	// number of log lines fetched, same as kubectl backend requests
+	apiLogTailLines = "100"
+)
+
+type (
+	// REST resource description
+	apiResource struct {
+		groupVersion string // e.g. "v1" or "apps/v1"
+		plural       string // e.g. "deployments"
+		namespaced   bool
+	}
+
+	// Kubernetes API server client, configured from kubeconfig
+	apiClient struct {
+		server     string
+		token      string
+		username   string
+		password   string
+		tlsConfig  *tls.Config
+		httpClient *http.Client
+
+		discoveryLock    sync.Mutex
+		groupVersionList []string                          // preferred group versions served by API server
+		discoveryList    map[string][]apiDiscoveryResource // resources of group version, keyed by group version
+	}
+
+	// Cluster implementation talking to Kubernetes API server, client is created from kubeconfig on first call
+	apiCluster struct {
+		contextName string
+		client      *apiClient
+		clientErr   error
+		once        sync.Once
+	}
+
+	// API list object, items are kept raw
+	apiObjectList struct {
+		Kind  string                   `json:"kind"`
+		Items []map[string]interface{} `json:"items"`
+	}
+
+	// API error answer
+	apiStatus struct {
+		Status  string `json:"status"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+
+	// discovery document of group version, e.g. /apis/apps/v1
+	apiDiscoveryDocument struct {
+		GroupVersion string                 `json:"groupVersion"`
+		Resources    []apiDiscoveryResource `json:"resources"`
+	}
+
+	apiDiscoveryResource struct {
+		Name       string `json:"name"`
+		Kind       string `json:"kind"`
+		Namespaced bool   `json:"namespaced"`
+	}
+
+	// list of API groups, /apis
+	apiGroupList struct {
+		Groups []struct {
+			PreferredVersion struct {
+				GroupVersion string `json:"groupVersion"`
+			} `json:"preferredVersion"`
+		} `json:"groups"`
+	}
+)
+
+var (
+	// resources known to fuse, keyed by lowercase kind, other kinds are resolved with discovery
+	apiResourceList = map[string]apiResource{
+		"pod":                     {"v1", "pods", true},
+		"service":                 {"v1", "services", true},
+		"configmap":               {"v1", "configmaps", true},
+		"secret":                  {"v1", "secrets", true},
+		"serviceaccount":          {"v1", "serviceaccounts", true},
+		"persistentvolumeclaim":   {"v1", "persistentvolumeclaims", true},
+		"endpoints":               {"v1", "endpoints", true},
+		"namespace":               {"v1", "namespaces", false},
+		"node":                    {"v1", "nodes", false},
+		"persistentvolume":        {"v1", "persistentvolumes", false},
+		"deployment":              {"apps/v1", "deployments", true},
+		"replicaset":              {"apps/v1", "replicasets", true},
+		"statefulset":             {"apps/v1", "statefulsets", true},
+		"daemonset":               {"apps/v1", "daemonsets", true},
+		"controllerrevision":      {"apps/v1", "controllerrevisions", true},
+		"job":                     {"batch/v1", "jobs", true},
+		"cronjob":                 {"batch/v1", "cronjobs", true},
+		"ingress":                 {"networking.k8s.io/v1", "ingresses", true},
+		"networkpolicy":           {"networking.k8s.io/v1", "networkpolicies", true},
+		"horizontalpodautoscaler": {"autoscaling/v1", "horizontalpodautoscalers", true},
+		"role":                    {"rbac.authorization.k8s.io/v1", "roles", true},
+		"rolebinding":             {"rbac.authorization.k8s.io/v1", "rolebindings", true},
+		"clusterrole":             {"rbac.authorization.k8s.io/v1", "clusterroles", false},
+		"clusterrolebinding":      {"rbac.authorization.k8s.io/v1", "clusterrolebindings", false},
+		"storageclass":            {"storage.k8s.io/v1", "storageclasses", false},
+	}
+)
+
+// api backend cluster of kubeconfig context, empty context is the current one of kubeconfig
+func newAPICluster(contextName string) *apiCluster {
+	return &apiCluster{
+		contextName: contextName,
+	}
+}
+
+// lazily build client, kubeconfig is read once
+func (c *apiCluster) getClient() (*apiClient, error) {
+	c.once.Do(func() {
+		if c.client == nil {
+			c.client, c.clientErr = newAPIClientFromKubeConfig(getKubeConfigPath(), c.contextName)
+		}
+	})
+
+	return c.client, c.clientErr
+}
+
+// GetResource fetch single resource of any kind, nil is returned for unknown kinds
+func (c *apiCluster) GetResource(ctx context.Context, namespace, kind, name string) (KubeResourceInterface, error) {
+	data, err := c.get(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := newParser().parseYaml(data)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	return items[0], nil
+}
+
+// GetDeployment fetch single deployment
+func (c *apiCluster) GetDeployment(ctx context.Context, namespace, name string) (*Deployment, error) {
+	r, err := c.GetResource(ctx, namespace, KindDeployment, name)
+	if err != nil || r == nil {
+		return nil, err
+	}
+
+	return r.ToDeployment()
+}
+
+// GetManifest fetch live resource as yaml, which can be applied back to cluster
+func (c *apiCluster) GetManifest(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	data, err := c.get(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return cleanManifest(data)
+}
+
+// ListDeployments fetch deployments matching selector
+func (c *apiCluster) ListDeployments(ctx context.Context, namespace string, selector []string) ([]Deployment, error) {
+	rlist, err := c.list(ctx, namespace, KindDeployment, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToDeploymentList(), nil
+}
+
+// ListPods fetch pods matching selector
+func (c *apiCluster) ListPods(ctx context.Context, namespace string, selector []string) ([]Pod, error) {
+	rlist, err := c.list(ctx, namespace, KindPod, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToPodList(), nil
+}
+
+// ListReplicaSets fetch replica sets matching selector, every replica set is returned for empty selector
+func (c *apiCluster) ListReplicaSets(ctx context.Context, namespace string, selector []string) ([]ReplicaSet, error) {
+	rlist, err := c.list(ctx, namespace, KindReplicaSet, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToReplicaSetList(), nil
+}
+
+// ListControllerRevisions fetch controller revisions matching selector
+func (c *apiCluster) ListControllerRevisions(ctx context.Context, namespace string, selector []string) ([]ControllerRevision, error) {
+	rlist, err := c.list(ctx, namespace, KindControllerRevision, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return rlist.ToControllerRevisionList(), nil
+}
+
+// ListResources fetch every resource of given kind
+func (c *apiCluster) ListResources(ctx context.Context, namespace, kind string) (ResourceList, error) {
+	return c.list(ctx, namespace, kind, nil)
+}
+
+// Apply apply configuration file to cluster, output mimics "kubectl apply -o name"
+func (c *apiCluster) Apply(ctx context.Context, configurationYaml string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	output, _, err := client.apply(ctx, configurationYaml, "")
+	return output, err
+}
+
+// ApplyDryRun apply configuration file without persisting changes, resulting objects are returned as yaml list,
+// client dry-run doesn't contact server at all
+func (c *apiCluster) ApplyDryRun(ctx context.Context, configurationYaml, mode string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	output, resultList, err := client.apply(ctx, configurationYaml, mode)
+	if err != nil {
+		return output, err
+	}
+
+	return yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      resultList,
+	})
+}
+
+// Undo rollback resource to exact revision, or to previous one if toRevision is 0
+func (c *apiCluster) Undo(ctx context.Context, namespace, kind, name string, toRevision int) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.undo(ctx, namespace, kind, name, toRevision)
+}
+
+// Delete delete resource
+func (c *apiCluster) Delete(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := client.findResource(ctx, "", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("fieldManager", apiFieldManager)
+
+	if result, err := client.do(ctx, http.MethodDelete, resource.path(namespace, name), query, "", nil); err != nil {
+		return result, err
+	}
+
+	return []byte(resource.objectName(kind, name) + " deleted\n"), nil
+}
+
+// Logs fetch logs of pod container
+func (c *apiCluster) Logs(ctx context.Context, namespace, pod, container string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("tailLines", apiLogTailLines)
+	query.Set("container", container)
+
+	path := apiResourceList[KindPod].path(namespace, pod) + "/log"
+	return client.do(ctx, http.MethodGet, path, query, "", nil)
+}
+
+// Exec execute command in pod container
+func (c *apiCluster) Exec(ctx context.Context, namespace, pod, container, command string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.exec(ctx, namespace, pod, container, command)
+}
+
+// Watch stream changes of resources of kind in namespace
+func (c *apiCluster) Watch(ctx context.Context, namespace, kind string) (ResourceWatch, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.watch(ctx, namespace, kind)
+}
+
+// fetch single object as JSON
+func (c *apiCluster) get(ctx context.Context, namespace, kind, name string) ([]byte, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := client.findResource(ctx, "", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.do(ctx, http.MethodGet, resource.path(namespace, name), nil, "", nil)
+}
+
+// fetch collection matching selector and parse it
+func (c *apiCluster) list(ctx context.Context, namespace, kind string, selector []string) (ResourceList, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := client.findResource(ctx, "", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if len(selector) > 0 {
+		query.Set("labelSelector", strings.Join(selector, ","))
+	}
+
+	list, err := client.list(ctx, resource.path(namespace, ""), query)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return newParser().parseYaml(data)
+}
+
+// build request path to resource collection or to single resource
+func (r apiResource) path(namespace, name string) string {
+	path := "/apis/" + r.groupVersion
+	if r.groupVersion == "v1" {
+		path = "/api/v1"
+	}
+
+	if r.namespaced && namespace != AllNamespaces {
+		path += "/namespaces/" + formatNamespace(namespace)
+	}
+
+	path += "/" + r.plural
+	if name != "" {
+		path += "/" + name
+	}
+
+	return path
+}
+
+// API group of resource, empty for core group
+func (r apiResource) group() string {
+	if i := strings.Index(r.groupVersion, "/"); i >= 0 {
+		return r.groupVersion[:i]
+	}
+	return ""
+}
+
+// object reference as printed by "kubectl -o name", e.g. deployment.apps/example
+func (r apiResource) objectName(kind, name string) string {
+	kind = strings.ToLower(kind)
+	if group := r.group(); group != "" {
+		kind = kind + "." + group
+	}
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// find resource by kind or plural name, as kubectl accepts both, kinds not known to fuse
+// are resolved with API discovery, apiVersion limits lookup to single group version
+func (c *apiClient) findResource(ctx context.Context, apiVersion, kind string) (*apiResource, error) {
+	kind = strings.ToLower(kind)
+	for name, r := range apiResourceList {
+		if (name == kind || r.plural == kind) && (apiVersion == "" || r.groupVersion == apiVersion) {
+			resource := r
+			return &resource, nil
+		}
+	}
+
+	groupVersionList := []string{apiVersion}
+	if apiVersion == "" {
+		var err error
+		if groupVersionList, err = c.discoverGroupVersions(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, groupVersion := range groupVersionList {
+		resourceList, err := c.discoverResources(ctx, groupVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range resourceList {
+			if strings.Contains(r.Name, "/") {
+				continue // subresource, e.g. deployments/scale
+			}
+			if strings.ToLower(r.Kind) == kind || r.Name == kind {
+				return &apiResource{groupVersion: groupVersion, plural: r.Name, namespaced: r.Namespaced}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("the server doesn't have a resource type %q", kind)
+}
+
+// core group version and preferred version of every API group, list is cached
+func (c *apiClient) discoverGroupVersions(ctx context.Context) ([]string, error) {
+	c.discoveryLock.Lock()
+	defer c.discoveryLock.Unlock()
+
+	if c.groupVersionList != nil {
+		return c.groupVersionList, nil
+	}
+
+	data, err := c.do(ctx, http.MethodGet, "/apis", nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	groupList := &apiGroupList{}
+	if err := json.Unmarshal(data, groupList); err != nil {
+		return nil, err
+	}
+
+	c.groupVersionList = []string{"v1"}
+	for _, group := range groupList.Groups {
+		c.groupVersionList = append(c.groupVersionList, group.PreferredVersion.GroupVersion)
+	}
+
+	return c.groupVersionList, nil
+}
+
+// resources served by group version, discovery documents are cached
+func (c *apiClient) discoverResources(ctx context.Context, groupVersion string) ([]apiDiscoveryResource, error) {
+	c.discoveryLock.Lock()
+	defer c.discoveryLock.Unlock()
+
+	if resourceList, ok := c.discoveryList[groupVersion]; ok {
+		return resourceList, nil
+	}
+
+	path := "/apis/" + groupVersion
+	if groupVersion == "v1" {
+		path = "/api/v1"
+	}
+
+	data, err := c.do(ctx, http.MethodGet, path, nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &apiDiscoveryDocument{}
+	if err := json.Unmarshal(data, document); err != nil {
+		return nil, err
+	}
+
+	if c.discoveryList == nil {
+		c.discoveryList = make(map[string][]apiDiscoveryResource)
+	}
+	c.discoveryList[groupVersion] = document.Resources
+
+	return document.Resources, nil
+}
+
+// perform request to API server, non 2xx response is an error, formatted as kubectl formats it
+func (c *apiClient) do(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) ([]byte, error) {
+	request, err := c.newRequest(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return data, newAPIError(method, path, response.Status, data)
+	}
+
+	return data, nil
+}
+
+// error of failed request, Status answer is reported as "Error from server (Reason): message"
+func newAPIError(method, path, responseStatus string, data []byte) error {
+	status := &apiStatus{}
+	if err := json.Unmarshal(data, status); err == nil && status.Reason != "" {
+		return fmt.Errorf("Error from server (%s): %s", status.Reason, status.Message)
+	}
+
+	return fmt.Errorf("%s %s failed: %s", method, path, responseStatus)
+}
+
+// build authorized API request, request is cancelled when context is done
+func (c *apiClient) newRequest(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) (*http.Request, error) {
+	requestURL := c.server + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", apiFieldManager)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	c.authorize(request)
+
+	return request, nil
+}
+
+// add credentials to request
+func (c *apiClient) authorize(request *http.Request) {
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		request.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// fetch collection and convert it to generic List kind, as kubectl does,
+// API server omits kind of every item in collection
+func (c *apiClient) list(ctx context.Context, path string, query url.Values) (*apiObjectList, error) {
+	data, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &apiObjectList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+
+	itemKind := strings.TrimSuffix(list.Kind, "List")
+	for _, item := range list.Items {
+		if _, ok := item["kind"]; !ok {
+			item["kind"] = itemKind
+		}
+	}
+
+	list.Kind = "List"
+	return list, nil
+}
+
+// Every object is applied with server-side apply under "fuse" field manager, ownership of fields
+// changed by others (client-side kubectl apply, kubectl edit, etc.) is forced, as configuration is
+// the source of truth, the same way kubectl apply overwrites them. Applied configuration
+// is recorded in last-applied-configuration annotation, as kubectl does, so diff works the same
+// for both backends. Client dry-run doesn't contact server at all.
+func (c *apiClient) apply(ctx context.Context, configurationYaml, dryRun string) ([]byte, []map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(configurationYaml)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resourceList, err := ParseResources(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output := make([]byte, 0)
+	resultList := make([]map[string]interface{}, 0)
+	for _, r := range resourceList {
+		object := make(map[string]interface{})
+		if err := yaml.Unmarshal(r.Manifest, &object); err != nil {
+			return output, nil, err
+		}
+		if err := setLastApplied(object); err != nil {
+			return output, nil, err
+		}
+
+		apiVersion, _ := object["apiVersion"].(string)
+		if dryRun == DryRunClient {
+			output = append(output, []byte(fmt.Sprintf("%s/%s\n", r.GetKind(), r.GetName()))...)
+			resultList = append(resultList, object)
+			continue
+		}
+
+		resource, err := c.findResource(ctx, apiVersion, r.Kind)
+		if err != nil {
+			return output, nil, err
+		}
+
+		body, err := json.Marshal(object)
+		if err != nil {
+			return output, nil, err
+		}
+
+		query := url.Values{}
+		query.Set("fieldManager", apiFieldManager)
+		query.Set("force", "true")
+		if dryRun == DryRunServer {
+			query.Set("dryRun", "All")
+		}
+
+		result, err := c.do(ctx, http.MethodPatch, resource.path(r.Metadata.Namespace, r.GetName()), query, "application/apply-patch+yaml", body)
+		if err != nil {
+			return append(output, result...), nil, err
+		}
+
+		resultObject := make(map[string]interface{})
+		if err := json.Unmarshal(result, &resultObject); err != nil {
+			return output, nil, err
+		}
+
+		output = append(output, []byte(resource.objectName(r.Kind, r.GetName())+"\n")...)
+		resultList = append(resultList, resultObject)
+	}
+
+	return output, resultList, nil
+}
+
+// store object itself in last-applied-configuration annotation
+func setLastApplied(object map[string]interface{}) error {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations != nil {
+		delete(annotations, AnnotationLastApplied)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	if len(annotations) == 0 {
+		annotations = make(map[string]interface{})
+		metadata["annotations"] = annotations
+	}
+	annotations[AnnotationLastApplied] = string(data) + "\n"
+
+	return nil
+}
+
+// "rollout undo", previous pod template is taken from controller history
+func (c *apiClient) undo(ctx context.Context, namespace, kind, name string, toRevision int) ([]byte, error) {
+	resource, err := c.findResource(ctx, "", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	path := resource.path(namespace, name)
+	data, err := c.do(ctx, http.MethodGet, path, nil, "", nil)
+	if err != nil {
+		return data, err
+	}
+
+	object := &Deployment{} // same metadata and spec.selector layout for every controller
+	if err := yaml.Unmarshal(data, object); err != nil {
+		return nil, err
+	}
+
+	var patch []byte
+	var patchType string
+	switch strings.ToLower(kind) {
+	case KindDeployment:
+		patch, err = c.buildDeploymentUndoPatch(ctx, object, toRevision)
+		patchType = "application/json-patch+json"
+	case KindStatefulSet, KindDaemonSet:
+		patch, err = c.buildControllerRevisionUndoPatch(ctx, object, toRevision)
+		patchType = "application/strategic-merge-patch+json"
+	default:
+		err = fmt.Errorf("no rollout history available for %s", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("fieldManager", apiFieldManager)
+
+	if result, err := c.do(ctx, http.MethodPatch, path, query, patchType, patch); err != nil {
+		return result, err
+	}
+
+	return []byte(resource.objectName(kind, object.GetName()) + " rolled back\n"), nil
+}
+
+// Deployment history is kept in owned ReplicaSets, pod template of selected
+// ReplicaSet replaces deployment pod template
+func (c *apiClient) buildDeploymentUndoPatch(ctx context.Context, d *Deployment, toRevision int) ([]byte, error) {
+	query := url.Values{}
+	query.Set("labelSelector", strings.Join(d.GetSelector(), ","))
+
+	list, err := c.list(ctx, apiResourceList[KindReplicaSet].path(d.GetNamespace(), ""), query)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := strconv.Atoi(d.Metadata.Annotations[AnnotationRevision])
+	var target map[string]interface{}
+	targetRevision := 0
+
+	for _, item := range ownedItems(list.Items, d.GetUUID()) {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		value, _ := annotations[AnnotationRevision].(string)
+		revision, _ := strconv.Atoi(value)
+
+		if isUndoTarget(revision, current, toRevision, targetRevision) {
+			target, targetRevision = item, revision
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("no rollout history found for deployment %s", d.GetKey())
+	}
+
+	spec, _ := target["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+			delete(labels, LabelPodTemplateHash)
+		}
+	}
+
+	return json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/spec/template", "value": template},
+	})
+}
+
+// StatefulSet and DaemonSet history is kept in owned ControllerRevisions,
+// revision data is a patch restoring previous state
+func (c *apiClient) buildControllerRevisionUndoPatch(ctx context.Context, object *Deployment, toRevision int) ([]byte, error) {
+	query := url.Values{}
+	query.Set("labelSelector", strings.Join(object.GetSelector(), ","))
+
+	list, err := c.list(ctx, apiResourceList[KindControllerRevision].path(object.GetNamespace(), ""), query)
+	if err != nil {
+		return nil, err
+	}
+
+	itemList := ownedItems(list.Items, object.GetUUID())
+
+	// latest controller revision is the current one
+	current := 0
+	for _, item := range itemList {
+		if revision := controllerRevisionNumber(item); revision > current {
+			current = revision
+		}
+	}
+
+	var target map[string]interface{}
+	targetRevision := 0
+	for _, item := range itemList {
+		revision := controllerRevisionNumber(item)
+		if isUndoTarget(revision, current, toRevision, targetRevision) {
+			target, targetRevision = item, revision
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("no rollout history found for %s %s", object.GetKind(), object.GetKey())
+	}
+
+	return json.Marshal(target["data"])
+}
+
+// revision is undo target, when it equals requested revision,
+// or it's the latest revision before current one
+func isUndoTarget(revision, current, toRevision, targetRevision int) bool {
+	if toRevision > 0 {
+		return revision == toRevision
+	}
+	return revision < current && revision > targetRevision
+}
+
+func controllerRevisionNumber(item map[string]interface{}) int {
+	revision, _ := item["revision"].(float64)
+	return int(revision)
+}
+
+// filter list items owned by resource with given uid
+func ownedItems(itemList []map[string]interface{}, uid string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0)
+	for _, item := range itemList {
+		metadata, _ := item["metadata"].(map[string]interface{})
+		ownerList, _ := metadata["ownerReferences"].([]interface{})
+		for _, owner := range ownerList {
+			ref, _ := owner.(map[string]interface{})
+			if ref["uid"] == uid {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,423 @@
+package kubectl
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	fakeAPIRequest struct {
+		method      string
+		uri         string
+		contentType string
+		userAgent   string
+		body        string
+	}
+
+	// fake API server, answers are keyed by "METHOD /path"
+	fakeAPIServer struct {
+		server   *httptest.Server
+		answers  map[string]string
+		requests []fakeAPIRequest
+	}
+)
+
+func newFakeAPIServer(answers map[string]string) *fakeAPIServer {
+	f := &fakeAPIServer{answers: answers}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		f.requests = append(f.requests, fakeAPIRequest{
+			method:      r.Method,
+			uri:         r.URL.RequestURI(),
+			contentType: r.Header.Get("Content-Type"),
+			userAgent:   r.Header.Get("User-Agent"),
+			body:        string(body),
+		})
+
+		answer, ok := f.answers[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","status":"Failure","reason":"NotFound","message":"the server could not find the requested resource"}`))
+			return
+		}
+		w.Write([]byte(answer))
+	}))
+
+	return f
+}
+
+func (f *fakeAPIServer) client() *apiClient {
+	return &apiClient{server: f.server.URL, token: "token", httpClient: http.DefaultClient}
+}
+
+func (f *fakeAPIServer) cluster() *apiCluster {
+	return &apiCluster{client: f.client()}
+}
+
+// write configuration to temporary file
+func writeTempManifest(content string) string {
+	manifest, _ := ioutil.TempFile("", "fuse")
+	manifest.WriteString(content)
+	manifest.Close()
+	return manifest.Name()
+}
+
+func TestNewCluster_APIBackend(t *testing.T) {
+	os.Setenv(ClusterBackendEnv, BackendAPI)
+	os.Setenv(ClusterContextEnv, "production")
+	cluster := NewCluster()
+	os.Unsetenv(ClusterBackendEnv)
+	os.Unsetenv(ClusterContextEnv)
+
+	c, ok := cluster.(*apiCluster)
+	assert.True(t, ok)
+	assert.Equal(t, "production", c.contextName)
+
+	_, ok = NewCluster().(*kubeCluster)
+	assert.True(t, ok)
+}
+
+func TestNewClusterForContext(t *testing.T) {
+	os.Setenv(ClusterContextEnv, "production")
+	cluster := NewClusterForContext("staging")
+	defaultCluster := NewClusterForContext("")
+	os.Unsetenv(ClusterContextEnv)
+
+	// environment is left as is
+	assert.Equal(t, "", os.Getenv(ClusterContextEnv))
+	assert.Equal(t, "staging", cluster.(*kubeCluster).contextName)
+	assert.Equal(t, "production", defaultCluster.(*kubeCluster).contextName)
+
+	call := cluster.(*kubeCluster).call(CommandPodLogs("default", "pod-1", "app"))
+	assert.Equal(t, "--context=staging", call.Cmd.getCommand().Args[1])
+}
+
+func TestAPICluster_GetResource(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis/apps/v1/namespaces/default/deployments/example": `{
+			"kind": "Deployment",
+			"metadata": {"name": "example", "namespace": "default", "generation": 3},
+			"status": {"observedGeneration": 3}
+		}`,
+	})
+	defer f.server.Close()
+
+	d, err := f.cluster().GetDeployment(context.Background(), "", "example")
+	assert.Nil(t, err)
+	assert.Equal(t, KindDeployment, d.GetKind())
+	assert.Equal(t, "example", d.GetName())
+}
+
+func TestAPICluster_ListPods(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /api/v1/namespaces/kube-system/pods": `{
+			"kind": "PodList",
+			"items": [
+				{"metadata": {"name": "pod-1", "namespace": "kube-system"}, "status": {"phase": "Running"}},
+				{"metadata": {"name": "pod-2", "namespace": "kube-system"}}
+			]
+		}`,
+	})
+	defer f.server.Close()
+
+	plist, err := f.cluster().ListPods(context.Background(), "kube-system", []string{"app=example", "tier in (web)"})
+	assert.Nil(t, err)
+	assert.Len(t, plist, 2)
+	assert.Equal(t, "kube-system/pod-1", plist[0].GetKey())
+	assert.Equal(t, PodStatusRunning, plist[0].Status.Phase)
+	assert.Equal(t, "/api/v1/namespaces/kube-system/pods?labelSelector=app%3Dexample%2Ctier+in+%28web%29", f.requests[0].uri)
+}
+
+func TestAPICluster_ListAllNamespaces(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis/apps/v1/replicasets": `{
+			"kind": "ReplicaSetList",
+			"items": [
+				{"metadata": {"name": "backend-1", "namespace": "default"}},
+				{"metadata": {"name": "backend-1", "namespace": "staging"}}
+			]
+		}`,
+	})
+	defer f.server.Close()
+
+	rsList, err := f.cluster().ListReplicaSets(context.Background(), AllNamespaces, nil)
+	assert.Nil(t, err)
+	assert.Len(t, rsList, 2)
+	assert.Equal(t, "staging", rsList[1].Metadata.Namespace)
+	assert.Equal(t, "/apis/apps/v1/replicasets", f.requests[0].uri)
+}
+
+func TestAPICluster_NotFound(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{})
+	defer f.server.Close()
+
+	r, err := f.cluster().GetResource(context.Background(), "default", KindStatefulSet, "absent")
+	assert.Nil(t, r)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "Error from server (NotFound): the server could not find the requested resource", err.Error())
+}
+
+func TestAPICluster_DiscoverResource(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis": `{"groups": [
+			{"name": "apps", "preferredVersion": {"groupVersion": "apps/v1"}},
+			{"name": "cert-manager.io", "preferredVersion": {"groupVersion": "cert-manager.io/v1"}}
+		]}`,
+		"GET /api/v1":       `{"groupVersion": "v1", "resources": [{"name": "pods", "kind": "Pod", "namespaced": true}]}`,
+		"GET /apis/apps/v1": `{"groupVersion": "apps/v1", "resources": [{"name": "deployments", "kind": "Deployment", "namespaced": true}]}`,
+		"GET /apis/cert-manager.io/v1": `{"groupVersion": "cert-manager.io/v1", "resources": [
+			{"name": "certificates/status", "kind": "Certificate", "namespaced": true},
+			{"name": "certificates", "kind": "Certificate", "namespaced": true}
+		]}`,
+		"GET /apis/cert-manager.io/v1/namespaces/default/certificates/example": `{
+			"apiVersion": "cert-manager.io/v1",
+			"kind": "Certificate",
+			"metadata": {"name": "example", "namespace": "default", "uid": "c-uid"},
+			"spec": {"secretName": "example-tls"}
+		}`,
+	})
+	defer f.server.Close()
+
+	cluster := f.cluster()
+	manifest, err := cluster.GetManifest(context.Background(), "default", "certificate", "example")
+	assert.Nil(t, err)
+	assert.Contains(t, string(manifest), "secretName: example-tls")
+	assert.NotContains(t, string(manifest), "c-uid")
+
+	// discovery documents are cached
+	_, err = cluster.GetManifest(context.Background(), "default", "certificate", "example")
+	assert.Nil(t, err)
+	assert.Len(t, f.requests, 6)
+
+	_, err = cluster.GetResource(context.Background(), "default", "unknown", "example")
+	assert.EqualError(t, err, `the server doesn't have a resource type "unknown"`)
+}
+
+func TestAPICluster_Logs(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /api/v1/namespaces/default/pods/pod-1/log": "hello world\n",
+	})
+	defer f.server.Close()
+
+	output, err := f.cluster().Logs(context.Background(), "default", "pod-1", "app")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world\n", string(output))
+	assert.Equal(t, "/api/v1/namespaces/default/pods/pod-1/log?container=app&tailLines=100", f.requests[0].uri)
+}
+
+func TestAPICluster_Apply(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"PATCH /apis/apps/v1/namespaces/default/deployments/test-deployment": `{}`,
+		"PATCH /api/v1/namespaces/production/services/test-service":          `{}`,
+		"GET /apis/policy/v1beta1": `{"groupVersion": "policy/v1beta1", "resources": [
+			{"name": "podsecuritypolicies", "kind": "PodSecurityPolicy", "namespaced": false}
+		]}`,
+		"PATCH /apis/policy/v1beta1/podsecuritypolicies/restricted": `{}`,
+	})
+	defer f.server.Close()
+
+	manifest := writeTempManifest(`---
+apiVersion: v1
+kind: Service
+metadata:
+  name: test-service
+  namespace: production
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: outdated
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-deployment
+---
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+`)
+	defer os.Remove(manifest)
+
+	output, err := f.cluster().Apply(context.Background(), manifest)
+	assert.Nil(t, err)
+	assert.Equal(t, "service/test-service\ndeployment.apps/test-deployment\npodsecuritypolicy.policy/restricted\n", string(output))
+	assert.Len(t, f.requests, 4)
+
+	// ownership of fields changed by others is forced
+	assert.Equal(t, "application/apply-patch+yaml", f.requests[0].contentType)
+	assert.Equal(t, "/api/v1/namespaces/production/services/test-service?fieldManager=fuse&force=true", f.requests[0].uri)
+	assert.JSONEq(t, `{
+		"apiVersion": "v1",
+		"kind": "Service",
+		"metadata": {
+			"name": "test-service",
+			"namespace": "production",
+			"annotations": {
+				"kubectl.kubernetes.io/last-applied-configuration": "{\"apiVersion\":\"v1\",\"kind\":\"Service\",\"metadata\":{\"name\":\"test-service\",\"namespace\":\"production\"}}\n"
+			}
+		}
+	}`, f.requests[0].body)
+
+	// resource of unknown group version is discovered
+	assert.Equal(t, "/apis/policy/v1beta1", f.requests[2].uri)
+	assert.Equal(t, "/apis/policy/v1beta1/podsecuritypolicies/restricted?fieldManager=fuse&force=true", f.requests[3].uri)
+}
+
+func TestAPICluster_ApplyDryRun(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"PATCH /api/v1/namespaces/default/configmaps/test-config": `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "test-config", "uid": "c-uid"}}`,
+	})
+	defer f.server.Close()
+
+	manifest := writeTempManifest("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test-config\n")
+	defer os.Remove(manifest)
+
+	output, err := f.cluster().ApplyDryRun(context.Background(), manifest, DryRunServer)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "kind: List")
+	assert.Contains(t, string(output), "uid: c-uid")
+	assert.Len(t, f.requests, 1)
+	assert.Equal(t, "/api/v1/namespaces/default/configmaps/test-config?dryRun=All&fieldManager=fuse&force=true", f.requests[0].uri)
+
+	// client dry-run doesn't contact server
+	output, err = f.cluster().ApplyDryRun(context.Background(), manifest, DryRunClient)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "name: test-config")
+	assert.Len(t, f.requests, 1)
+}
+
+func TestAPICluster_UndoDeployment(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis/apps/v1/namespaces/default/deployments/example": `{
+			"kind": "Deployment",
+			"metadata": {"name": "example", "uid": "d-uid", "annotations": {"deployment.kubernetes.io/revision": "3"}},
+			"spec": {"selector": {"matchLabels": {"app": "example"}}}
+		}`,
+		"GET /apis/apps/v1/namespaces/default/replicasets": `{
+			"kind": "ReplicaSetList",
+			"items": [
+				{
+					"metadata": {"name": "example-1", "annotations": {"deployment.kubernetes.io/revision": "1"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "1"}}}}
+				},
+				{
+					"metadata": {"name": "example-2", "annotations": {"deployment.kubernetes.io/revision": "2"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "2"}}}}
+				},
+				{
+					"metadata": {"name": "example-3", "annotations": {"deployment.kubernetes.io/revision": "3"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "3"}}}}
+				},
+				{
+					"metadata": {"name": "other-7", "annotations": {"deployment.kubernetes.io/revision": "2"}, "ownerReferences": [{"uid": "other-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "other"}}}}
+				}
+			]
+		}`,
+		"PATCH /apis/apps/v1/namespaces/default/deployments/example": `{}`,
+	})
+	defer f.server.Close()
+
+	output, err := f.cluster().Undo(context.Background(), "default", KindDeployment, "example", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "deployment.apps/example rolled back\n", string(output))
+
+	patch := f.requests[len(f.requests)-1]
+	assert.Equal(t, "application/json-patch+json", patch.contentType)
+	assert.JSONEq(t, `[{"op":"replace","path":"/spec/template","value":{"metadata":{"labels":{"app":"example"}}}}]`, patch.body)
+}
+
+// every change is made by "fuse" field manager, so rolled back resource can be applied again
+func TestAPICluster_ApplyUndoApply(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis/apps/v1/namespaces/default/deployments/example": `{
+			"kind": "Deployment",
+			"metadata": {"name": "example", "uid": "d-uid", "annotations": {"deployment.kubernetes.io/revision": "3"}},
+			"spec": {"selector": {"matchLabels": {"app": "example"}}}
+		}`,
+		"GET /apis/apps/v1/namespaces/default/replicasets": `{
+			"kind": "ReplicaSetList",
+			"items": [
+				{
+					"metadata": {"name": "example-1", "annotations": {"deployment.kubernetes.io/revision": "1"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "1"}}}}
+				},
+				{
+					"metadata": {"name": "example-2", "annotations": {"deployment.kubernetes.io/revision": "2"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "2"}}}}
+				},
+				{
+					"metadata": {"name": "example-3", "annotations": {"deployment.kubernetes.io/revision": "3"}, "ownerReferences": [{"uid": "d-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "example", "pod-template-hash": "3"}}}}
+				},
+				{
+					"metadata": {"name": "other-7", "annotations": {"deployment.kubernetes.io/revision": "2"}, "ownerReferences": [{"uid": "other-uid"}]},
+					"spec": {"template": {"metadata": {"labels": {"app": "other"}}}}
+				}
+			]
+		}`,
+		"PATCH /apis/apps/v1/namespaces/default/deployments/example": `{}`,
+	})
+	defer f.server.Close()
+
+	manifest := writeTempManifest("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: example\n")
+	defer os.Remove(manifest)
+
+	cluster := f.cluster()
+	_, err := cluster.Apply(context.Background(), manifest)
+	assert.Nil(t, err)
+	_, err = cluster.Undo(context.Background(), "default", KindDeployment, "example", 0)
+	assert.Nil(t, err)
+	_, err = cluster.Apply(context.Background(), manifest)
+	assert.Nil(t, err)
+
+	patchList := make([]string, 0)
+	for _, request := range f.requests {
+		assert.Equal(t, "fuse", request.userAgent)
+		if request.method == http.MethodPatch {
+			patchList = append(patchList, request.contentType+" "+request.uri)
+		}
+	}
+	assert.Equal(t, []string{
+		"application/apply-patch+yaml /apis/apps/v1/namespaces/default/deployments/example?fieldManager=fuse&force=true",
+		"application/json-patch+json /apis/apps/v1/namespaces/default/deployments/example?fieldManager=fuse",
+		"application/apply-patch+yaml /apis/apps/v1/namespaces/default/deployments/example?fieldManager=fuse&force=true",
+	}, patchList)
+}
+
+func TestAPICluster_UndoStatefulSetWithoutHistory(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /apis/apps/v1/namespaces/default/statefulsets/example": `{
+			"kind": "StatefulSet",
+			"metadata": {"name": "example", "uid": "s-uid"}
+		}`,
+		"GET /apis/apps/v1/namespaces/default/controllerrevisions": `{
+			"kind": "ControllerRevisionList",
+			"items": [{"metadata": {"name": "example-1", "ownerReferences": [{"uid": "s-uid"}]}, "revision": 1}]
+		}`,
+	})
+	defer f.server.Close()
+
+	_, err := f.cluster().Undo(context.Background(), "default", KindStatefulSet, "example", 0)
+	assert.Error(t, err)
+	assert.Equal(t, "no rollout history found for statefulset default/example", err.Error())
+}
+
+func TestAPICluster_Delete(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"DELETE /apis/apps/v1/namespaces/default/deployments/example": `{"kind": "Status", "status": "Success"}`,
+	})
+	defer f.server.Close()
+
+	output, err := f.cluster().Delete(context.Background(), "default", KindDeployment, "example")
+	assert.Nil(t, err)
+	assert.Equal(t, "deployment.apps/example deleted\n", string(output))
+	assert.Equal(t, "DELETE", f.requests[0].method)
+	assert.Equal(t, "/apis/apps/v1/namespaces/default/deployments/example?fieldManager=fuse", f.requests[0].uri)
+}
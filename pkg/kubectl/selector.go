@@ -0,0 +1,131 @@
+package kubectl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// SelectorOpIn requires label value to be one of values
+	SelectorOpIn = "In"
+
+	// SelectorOpNotIn requires label value not to be one of values
+	SelectorOpNotIn = "NotIn"
+
+	// SelectorOpExists requires label to be present
+	SelectorOpExists = "Exists"
+
+	// SelectorOpDoesNotExist requires label to be absent
+	SelectorOpDoesNotExist = "DoesNotExist"
+)
+
+type (
+	// LabelSelectorRequirement is single set-based requirement of LabelSelector
+	LabelSelectorRequirement struct {
+		Key      string   `yaml:"key"`
+		Operator string   `yaml:"operator"`
+		Values   []string `yaml:"values"`
+	}
+
+	// LabelSelector is k8s label selector defined in spec.selector of controllers
+	LabelSelector struct {
+		MatchLabels      map[string]string          `yaml:"matchLabels"`
+		MatchExpressions []LabelSelectorRequirement `yaml:"matchExpressions"`
+	}
+)
+
+// IsEmpty check selector has no requirements
+func (s *LabelSelector) IsEmpty() bool {
+	return len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+// GetSelector return slice of requirements in kubectl --selector syntax,
+// e.g. "app=example", "tier in (web,api)", "!canary"
+func (s *LabelSelector) GetSelector() []string {
+	selectorList := labelsToSelector(s.MatchLabels)
+	for _, r := range s.MatchExpressions {
+		switch r.Operator {
+		case SelectorOpIn:
+			selectorList = append(selectorList, fmt.Sprintf("%s in (%s)", r.Key, strings.Join(r.Values, ",")))
+		case SelectorOpNotIn:
+			selectorList = append(selectorList, fmt.Sprintf("%s notin (%s)", r.Key, strings.Join(r.Values, ",")))
+		case SelectorOpExists:
+			selectorList = append(selectorList, r.Key)
+		case SelectorOpDoesNotExist:
+			selectorList = append(selectorList, fmt.Sprintf("!%s", r.Key))
+		}
+	}
+
+	return selectorList
+}
+
+// convert label map into equality-based selector list, sorted by key
+func labelsToSelector(labels map[string]string) []string {
+	keyList := make([]string, 0)
+	for key := range labels {
+		keyList = append(keyList, key)
+	}
+	sort.Strings(keyList)
+
+	selectorList := make([]string, 0)
+	for _, key := range keyList {
+		selectorList = append(selectorList, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+
+	return selectorList
+}
+
+// MatchSelector check labels satisfy every requirement of selector list, as rendered by GetSelector
+func MatchSelector(labels map[string]string, selector []string) bool {
+	for _, requirement := range selector {
+		if !matchRequirement(labels, strings.TrimSpace(requirement)) {
+			return false
+		}
+	}
+	return true
+}
+
+// check labels satisfy single requirement, e.g. "app=example" or "tier in (web,api)"
+func matchRequirement(labels map[string]string, requirement string) bool {
+	// set-based requirements
+	for _, op := range []string{" notin ", " in "} {
+		if i := strings.Index(requirement, op); i >= 0 {
+			key := strings.TrimSpace(requirement[:i])
+			values := strings.Trim(strings.TrimSpace(requirement[i+len(op):]), "()")
+
+			value, exists := labels[key]
+			found := exists && stringInList(value, strings.Split(values, ","))
+			if op == " in " {
+				return found
+			}
+			return !found
+		}
+	}
+
+	if strings.HasPrefix(requirement, "!") {
+		_, exists := labels[strings.TrimPrefix(requirement, "!")]
+		return !exists
+	}
+
+	// equality-based requirements
+	if i := strings.Index(requirement, "!="); i >= 0 {
+		return labels[requirement[:i]] != requirement[i+2:]
+	}
+	if parts := strings.SplitN(strings.Replace(requirement, "==", "=", 1), "=", 2); len(parts) == 2 {
+		value, exists := labels[parts[0]]
+		return exists && value == parts[1]
+	}
+
+	_, exists := labels[requirement]
+	return exists
+}
+
+func stringInList(s string, list []string) bool {
+	for _, item := range list {
+		if strings.TrimSpace(item) == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package kubectl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLabelSelector_GetSelector(t *testing.T) {
+	s := LabelSelector{
+		MatchLabels: map[string]string{
+			"tier": "web",
+			"app":  "example",
+		},
+		MatchExpressions: []LabelSelectorRequirement{
+			{Key: "env", Operator: SelectorOpIn, Values: []string{"staging", "production"}},
+			{Key: "track", Operator: SelectorOpNotIn, Values: []string{"canary"}},
+			{Key: "release", Operator: SelectorOpExists},
+			{Key: "legacy", Operator: SelectorOpDoesNotExist},
+			{Key: "unknown", Operator: "Unknown"},
+		},
+	}
+
+	assert.False(t, s.IsEmpty())
+	assert.Equal(t, []string{
+		"app=example",
+		"tier=web",
+		"env in (staging,production)",
+		"track notin (canary)",
+		"release",
+		"!legacy",
+	}, s.GetSelector())
+}
+
+func TestLabelSelector_Empty(t *testing.T) {
+	s := LabelSelector{}
+
+	assert.True(t, s.IsEmpty())
+	assert.Empty(t, s.GetSelector())
+}
+
+func TestMatchSelector(t *testing.T) {
+	labels := map[string]string{
+		"app":  "example",
+		"tier": "web",
+	}
+
+	testCaseList := []struct {
+		selector []string
+		expected bool
+	}{
+		{selector: []string{}, expected: true},
+		{selector: []string{"app=example"}, expected: true},
+		{selector: []string{"app==example", "tier=web"}, expected: true},
+		{selector: []string{"app=example", "tier=api"}, expected: false},
+		{selector: []string{"app!=other"}, expected: true},
+		{selector: []string{"tier in (web,api)"}, expected: true},
+		{selector: []string{"tier in (api)"}, expected: false},
+		{selector: []string{"tier notin (api)"}, expected: true},
+		{selector: []string{"release notin (canary)"}, expected: true},
+		{selector: []string{"app"}, expected: true},
+		{selector: []string{"release"}, expected: false},
+		{selector: []string{"!release"}, expected: true},
+		{selector: []string{"!app"}, expected: false},
+	}
+
+	for _, testCase := range testCaseList {
+		assert.Equal(t, testCase.expected, MatchSelector(labels, testCase.selector), testCase.selector)
+	}
+}
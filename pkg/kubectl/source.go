@@ -0,0 +1,119 @@
+package kubectl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SourceStdin is configuration source name which reads configuration from standard input
+	SourceStdin = "-"
+)
+
+var (
+	// file extensions picked from configuration directories, same as kubectl does
+	sourceExtensionList = []string{".yml", ".yaml", ".json"}
+)
+
+// ReadConfiguration merge every configuration source (file, directory or "-" for stdin) into single
+// multi-document yaml, directories are scanned in lexical order, nested ones only if recursive is set
+func ReadConfiguration(sourceList []string, recursive bool, stdin io.Reader) ([]byte, error) {
+	if len(sourceList) == 0 {
+		return nil, errors.New("no configuration source provided")
+	}
+
+	merged := &bytes.Buffer{}
+	isStdinRead := false
+	for _, source := range sourceList {
+		if source == SourceStdin {
+			if isStdinRead {
+				return nil, errors.New("standard input can be used as configuration source only once")
+			}
+			isStdinRead = true
+
+			data, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return nil, err
+			}
+			appendDocument(merged, data)
+			continue
+		}
+
+		fileList, err := collectSourceFiles(source, recursive)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileList) == 0 {
+			return nil, fmt.Errorf("no configuration files found in %s", source)
+		}
+
+		for _, filename := range fileList {
+			data, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			appendDocument(merged, data)
+		}
+	}
+
+	return merged.Bytes(), nil
+}
+
+// list files of source in lexical order, source itself is returned if it's not a directory
+func collectSourceFiles(source string, recursive bool) ([]string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{source}, nil
+	}
+
+	fileList := make([]string, 0)
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != source && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isSourceFile(path) {
+			fileList = append(fileList, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fileList, nil
+}
+
+// check file has one of configuration extensions
+func isSourceFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, sourceExt := range sourceExtensionList {
+		if ext == sourceExt {
+			return true
+		}
+	}
+	return false
+}
+
+// append document separated from previous one, so documents of different files are never glued together
+func appendDocument(merged *bytes.Buffer, data []byte) {
+	merged.WriteString("---\n")
+	merged.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		merged.WriteString("\n")
+	}
+}
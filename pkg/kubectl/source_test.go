@@ -0,0 +1,46 @@
+package kubectl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func parseSourceKinds(t *testing.T, data []byte) []string {
+	resourceList, err := ParseResources(data)
+	assert.Nil(t, err)
+
+	kindList := make([]string, 0)
+	for _, r := range resourceList {
+		kindList = append(kindList, r.GetKind())
+	}
+	return kindList
+}
+
+func TestReadConfiguration_Directory(t *testing.T) {
+	data, err := ReadConfiguration([]string{"testdata/source"}, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"configmap", "service"}, parseSourceKinds(t, data))
+
+	data, err = ReadConfiguration([]string{"testdata/source"}, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"configmap", "service", "deployment"}, parseSourceKinds(t, data))
+}
+
+func TestReadConfiguration_FilesAndStdin(t *testing.T) {
+	stdin := strings.NewReader("kind: Secret\nmetadata:\n  name: secret\n")
+	data, err := ReadConfiguration([]string{"testdata/source/nested/deployment.json", "-", "testdata/source/02-service.yaml"}, false, stdin)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deployment", "secret", "service"}, parseSourceKinds(t, data))
+}
+
+func TestReadConfiguration_Errors(t *testing.T) {
+	_, err := ReadConfiguration([]string{}, false, nil)
+	assert.NotNil(t, err)
+
+	_, err = ReadConfiguration([]string{"-", "-"}, false, strings.NewReader(""))
+	assert.NotNil(t, err)
+
+	_, err = ReadConfiguration([]string{"testdata/missing.yml"}, false, nil)
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,8 @@ package kubectl
 import (
 	"errors"
 	"fmt"
+	"github.com/ghodss/yaml"
+	"strconv"
 	"strings"
 )
 
@@ -10,9 +12,24 @@ const (
 	// KindDeployment name of Deployment resource type
 	KindDeployment = "deployment"
 
+	// KindStatefulSet name of StatefulSet resource type
+	KindStatefulSet = "statefulset"
+
+	// KindDaemonSet name of DaemonSet resource type
+	KindDaemonSet = "daemonset"
+
+	// KindJob name of Job resource type
+	KindJob = "job"
+
+	// KindCronJob name of CronJob resource type
+	KindCronJob = "cronjob"
+
 	// KindReplicaSet name of ReplicaSet resource type
 	KindReplicaSet = "replicaset"
 
+	// KindControllerRevision name of ControllerRevision resource type
+	KindControllerRevision = "controllerrevision"
+
 	// KindNamespace name of Namespace resource type
 	KindNamespace = "namespace"
 
@@ -25,6 +42,48 @@ const (
 	// PodStatusRunning string representation of running pod
 	PodStatusRunning = "Running"
 
+	// PodStatusSucceeded string representation of pod terminated with success
+	PodStatusSucceeded = "Succeeded"
+
+	// PodStatusFailed string representation of pod terminated with failure
+	PodStatusFailed = "Failed"
+
+	// JobConditionComplete is condition type of successfully completed Job
+	JobConditionComplete = "Complete"
+
+	// JobConditionFailed is condition type of failed Job
+	JobConditionFailed = "Failed"
+
+	// DeploymentConditionProgressing is condition type of Deployment making progress or failed to do so
+	DeploymentConditionProgressing = "Progressing"
+
+	// DeploymentConditionReplicaFailure is condition type of Deployment unable to create or delete pods
+	DeploymentConditionReplicaFailure = "ReplicaFailure"
+
+	// DeploymentReasonProgressDeadlineExceeded is reason of Progressing condition when progressDeadlineSeconds is reached
+	DeploymentReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+	// ConditionTrue is status of condition in effect
+	ConditionTrue = "True"
+
+	// ConditionFalse is status of condition not in effect
+	ConditionFalse = "False"
+
+	// PodConditionScheduled is condition type of pod bound to node
+	PodConditionScheduled = "PodScheduled"
+
+	// PodReasonUnschedulable is reason of PodScheduled condition when no node fits the pod
+	PodReasonUnschedulable = "Unschedulable"
+
+	// AnnotationRevision is annotation holding Deployment and ReplicaSet revision number
+	AnnotationRevision = "deployment.kubernetes.io/revision"
+
+	// LabelPodTemplateHash is label which ReplicaSet and its pods are marked with
+	LabelPodTemplateHash = "pod-template-hash"
+
+	// LabelControllerRevisionHash is label which StatefulSet and DaemonSet pods are marked with
+	LabelControllerRevisionHash = "controller-revision-hash"
+
 	// ClusterContextEnv is the name of environment variable to get kubectl requested context
 	ClusterContextEnv = "CLUSTER_CONTEXT"
 
@@ -32,13 +91,25 @@ const (
 	strategyTypeRollingUpdate = "RollingUpdate"
 )
 
+var (
+	// container waiting reasons, pod with such container will never become ready without intervention
+	fatalWaitingReasonList = []string{
+		"CrashLoopBackOff",
+		"ImagePullBackOff",
+		"ErrImagePull",
+		"InvalidImageName",
+		"CreateContainerConfigError",
+	}
+)
+
 type (
 	resourceMetadata struct {
-		Name       string            `yaml:"name"`
-		Namespace  string            `yaml:"namespace"`
-		Labels     map[string]string `yaml:"labels"`
-		Generation int               `yaml:"generation"`
-		UID        string            `yaml:"uid"`
+		Name        string            `yaml:"name"`
+		Namespace   string            `yaml:"namespace"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+		Generation  int               `yaml:"generation"`
+		UID         string            `yaml:"uid"`
 	}
 
 	resourceStatus struct {
@@ -46,8 +117,46 @@ type (
 		ObservedGeneration  int    `yaml:"observedGeneration"`  // current generation value
 		Replicas            int    `yaml:"replicas"`            // requested number of instances
 		UpdatedReplicas     int    `yaml:"updatedReplicas"`     // up-to-date instances
+		ReadyReplicas       int    `yaml:"readyReplicas"`       // instances with Ready condition
 		UnavailableReplicas int    `yaml:"unavailableReplicas"` // total number of unavailable instances
 		Phase               string `yaml:"phase"`               // pod status
+
+		Conditions        []Condition               `yaml:"conditions"`
+		ContainerStatuses []resourceContainerStatus `yaml:"containerStatuses"` // Pod specific
+
+		InitContainerStatuses []resourceContainerStatus `yaml:"initContainerStatuses"` // Pod specific
+
+		// StatefulSet specific
+		CurrentRevision string `yaml:"currentRevision"` // revision used to generate current pods
+		UpdateRevision  string `yaml:"updateRevision"`  // revision used to generate pods being rolled out
+
+		// DaemonSet specific
+		DesiredNumberScheduled int `yaml:"desiredNumberScheduled"` // nodes that should be running the daemon pod
+		UpdatedNumberScheduled int `yaml:"updatedNumberScheduled"` // nodes running updated daemon pod
+		NumberReady            int `yaml:"numberReady"`            // nodes running ready daemon pod
+		NumberUnavailable      int `yaml:"numberUnavailable"`      // nodes without available daemon pod
+
+		// Job specific
+		Active    int `yaml:"active"`    // number of running pods
+		Succeeded int `yaml:"succeeded"` // number of pods reached phase Succeeded
+		Failed    int `yaml:"failed"`    // number of pods reached phase Failed
+	}
+
+	resourceContainerStateWaiting struct {
+		Reason  string `yaml:"reason"` // e.g. CrashLoopBackOff
+		Message string `yaml:"message"`
+	}
+
+	resourceContainerState struct {
+		Waiting *resourceContainerStateWaiting `yaml:"waiting"`
+	}
+
+	resourceContainerStatus struct {
+		Name         string                 `yaml:"name"`
+		Ready        bool                   `yaml:"ready"`
+		RestartCount int                    `yaml:"restartCount"`
+		State        resourceContainerState `yaml:"state"`
+		ImageID      string                 `yaml:"imageID"` // docker-pullable://example.com:80/dalee/image@sha256:ffff...
 	}
 
 	resourceContainer struct {
@@ -56,7 +165,28 @@ type (
 	}
 
 	resourceContainerSpec struct {
-		Containers []resourceContainer `yaml:"containers"`
+		Containers     []resourceContainer `yaml:"containers"`
+		InitContainers []resourceContainer `yaml:"initContainers"`
+	}
+
+	// every place pod spec can be defined in: Pod, pod template of workload or job template of CronJob
+	resourceWorkloadSpec struct {
+		Containers     []resourceContainer `yaml:"containers"`
+		InitContainers []resourceContainer `yaml:"initContainers"`
+		Template       resourceTemplate    `yaml:"template"`
+		JobTemplate    struct {
+			Spec struct {
+				Template resourceTemplate `yaml:"template"`
+			} `yaml:"spec"`
+		} `yaml:"jobTemplate"`
+	}
+
+	// CronJob spec, pods are defined by template of job template
+	resourceCronJobSpec struct {
+		Schedule    string `yaml:"schedule"`
+		JobTemplate struct {
+			Spec resourceSpec `yaml:"spec"`
+		} `yaml:"jobTemplate"`
 	}
 
 	resourceMetadataSpec struct {
@@ -69,8 +199,9 @@ type (
 	}
 
 	resourceStrategyRolling struct {
-		MaxSurge       int `yaml:"maxSurge"`
-		MaxUnavailable int `yaml:"maxUnavailable"`
+		MaxSurge       IntOrString `yaml:"maxSurge"`
+		MaxUnavailable IntOrString `yaml:"maxUnavailable"`
+		Partition      int         `yaml:"partition"` // StatefulSet only
 	}
 
 	resourceStrategy struct {
@@ -79,9 +210,14 @@ type (
 	}
 
 	resourceSpec struct {
-		Replicas int              `yaml:"replicas"`
-		Template resourceTemplate `yaml:"template"`
-		Strategy resourceStrategy `yaml:"strategy"`
+		Replicas       int              `yaml:"replicas"`
+		Selector       LabelSelector    `yaml:"selector"`
+		Template       resourceTemplate `yaml:"template"`
+		Strategy       resourceStrategy `yaml:"strategy"`       // Deployment
+		UpdateStrategy resourceStrategy `yaml:"updateStrategy"` // StatefulSet and DaemonSet
+		Completions    int              `yaml:"completions"`    // Job
+
+		ProgressDeadlineSeconds int `yaml:"progressDeadlineSeconds"` // Deployment, seconds to make progress before rollout is failed
 	}
 
 	kubeResourceList struct {
@@ -109,14 +245,48 @@ type (
 		Items []Deployment `yaml:"items"`
 	}
 
+	statefulSetList struct {
+		Items []StatefulSet `yaml:"items"`
+	}
+
+	daemonSetList struct {
+		Items []DaemonSet `yaml:"items"`
+	}
+
+	jobList struct {
+		Items []Job `yaml:"items"`
+	}
+
+	cronJobList struct {
+		Items []CronJob `yaml:"items"`
+	}
+
 	replicaSetList struct {
 		Items []ReplicaSet `yaml:"items"`
 	}
 
+	controllerRevisionList struct {
+		Items []ControllerRevision `yaml:"items"`
+	}
+
 	namespaceList struct {
 		Items []Namespace `yaml:"items"`
 	}
 
+	// Condition is an observation of resource state, e.g. Progressing or Failed
+	Condition struct {
+		Type    string `yaml:"type"`
+		Status  string `yaml:"status"` // True, False or Unknown
+		Reason  string `yaml:"reason"`
+		Message string `yaml:"message"`
+	}
+
+	// FailingResourceInterface is interface to resources able to report their rollout will never succeed
+	FailingResourceInterface interface {
+		IsFailed() bool
+		GetFailureReason() string
+	}
+
 	// KubeResourceInterface is common interface to all k8s resource types
 	KubeResourceInterface interface {
 		GetKind() string
@@ -124,6 +294,24 @@ type (
 		ToDeployment() (*Deployment, error)
 	}
 
+	// RolloutResourceInterface is common interface to resources which rollout can be monitored
+	RolloutResourceInterface interface {
+		KubeResourceInterface
+		GetNamespace() string
+		GetKey() string
+		GetSelector() []string
+		GetPodSelector() []string
+		IsReady() bool
+		GetStatusString() string
+	}
+
+	// ImageResourceInterface is interface to resources defining pods, images of containers
+	// and init containers can be extracted from them
+	ImageResourceInterface interface {
+		KubeResourceInterface
+		GetImages() []string
+	}
+
 	// ResourceList is an alias for []KubeResourceInterface
 	ResourceList []KubeResourceInterface
 
@@ -143,6 +331,37 @@ type (
 		Status   resourceStatus   `yaml:"status"`
 	}
 
+	// StatefulSet is k8s StatefulSet resource
+	StatefulSet struct {
+		Kind     string           `yaml:"kind"`
+		Metadata resourceMetadata `yaml:"metadata"`
+		Spec     resourceSpec     `yaml:"spec"`
+		Status   resourceStatus   `yaml:"status"`
+	}
+
+	// DaemonSet is k8s DaemonSet resource
+	DaemonSet struct {
+		Kind     string           `yaml:"kind"`
+		Metadata resourceMetadata `yaml:"metadata"`
+		Spec     resourceSpec     `yaml:"spec"`
+		Status   resourceStatus   `yaml:"status"`
+	}
+
+	// Job is k8s Job resource
+	Job struct {
+		Kind     string           `yaml:"kind"`
+		Metadata resourceMetadata `yaml:"metadata"`
+		Spec     resourceSpec     `yaml:"spec"`
+		Status   resourceStatus   `yaml:"status"`
+	}
+
+	// CronJob is k8s CronJob resource
+	CronJob struct {
+		Kind     string              `yaml:"kind"`
+		Metadata resourceMetadata    `yaml:"metadata"`
+		Spec     resourceCronJobSpec `yaml:"spec"`
+	}
+
 	// ReplicaSet is k8s ReplicaSet resource
 	ReplicaSet struct {
 		Kind     string           `yaml:"kind"`
@@ -151,11 +370,25 @@ type (
 		Status   resourceStatus   `yaml:"status"`
 	}
 
+	// ControllerRevision is k8s ControllerRevision resource (StatefulSet and DaemonSet history)
+	ControllerRevision struct {
+		Kind     string           `yaml:"kind"`
+		Metadata resourceMetadata `yaml:"metadata"`
+		Revision int              `yaml:"revision"`
+	}
+
 	// Namespace is k8s Namespace resource
 	Namespace struct {
 		Kind     string           `yaml:"kind"`
 		Metadata resourceMetadata `yaml:"metadata"`
 	}
+
+	// Resource is k8s resource of any kind, only kind and metadata are decoded
+	Resource struct {
+		Kind     string           `yaml:"kind"`
+		Metadata resourceMetadata `yaml:"metadata"`
+		Manifest []byte           `yaml:"-" json:"-"` // document as defined in file
+	}
 )
 
 // FilteredByKind return filtered slice of resources by kind
@@ -183,6 +416,83 @@ func (rl ResourceList) ToDeploymentList() []Deployment {
 	return dlist
 }
 
+// ToStatefulSetList is helper to convert []KubeResourceInterface to []StatefulSet
+func (rl ResourceList) ToStatefulSetList() []StatefulSet {
+	slist := make([]StatefulSet, 0)
+	for _, obj := range rl {
+		if obj.GetKind() == KindStatefulSet {
+			s, _ := obj.(*StatefulSet)
+			slist = append(slist, *s)
+		}
+	}
+
+	return slist
+}
+
+// ToDaemonSetList is helper to convert []KubeResourceInterface to []DaemonSet
+func (rl ResourceList) ToDaemonSetList() []DaemonSet {
+	dlist := make([]DaemonSet, 0)
+	for _, obj := range rl {
+		if obj.GetKind() == KindDaemonSet {
+			d, _ := obj.(*DaemonSet)
+			dlist = append(dlist, *d)
+		}
+	}
+
+	return dlist
+}
+
+// ToJobList is helper to convert []KubeResourceInterface to []Job
+func (rl ResourceList) ToJobList() []Job {
+	jlist := make([]Job, 0)
+	for _, obj := range rl {
+		if obj.GetKind() == KindJob {
+			j, _ := obj.(*Job)
+			jlist = append(jlist, *j)
+		}
+	}
+
+	return jlist
+}
+
+// ToImageResourceList is helper to extract resources defining pods, order of resources is preserved
+func (rl ResourceList) ToImageResourceList() []ImageResourceInterface {
+	ilist := make([]ImageResourceInterface, 0)
+	for _, obj := range rl {
+		if r, ok := obj.(ImageResourceInterface); ok {
+			ilist = append(ilist, r)
+		}
+	}
+
+	return ilist
+}
+
+// ToRolloutList is helper to extract resources which rollout can be monitored
+// (Deployment, StatefulSet and DaemonSet), order of resources is preserved
+func (rl ResourceList) ToRolloutList() []RolloutResourceInterface {
+	rlist := make([]RolloutResourceInterface, 0)
+	for _, obj := range rl {
+		if r, ok := obj.(RolloutResourceInterface); ok {
+			rlist = append(rlist, r)
+		}
+	}
+
+	return rlist
+}
+
+// ToControllerRevisionList is helper to convert []KubeResourceInterface to []ControllerRevision
+func (rl ResourceList) ToControllerRevisionList() []ControllerRevision {
+	clist := make([]ControllerRevision, 0)
+	for _, obj := range rl {
+		if obj.GetKind() == KindControllerRevision {
+			c, _ := obj.(*ControllerRevision)
+			clist = append(clist, *c)
+		}
+	}
+
+	return clist
+}
+
 // ToReplicaSetList is helper to convert []KubeResourceInterface to []ReplicaSet
 func (rl ResourceList) ToReplicaSetList() []ReplicaSet {
 	rlist := make([]ReplicaSet, 0)
@@ -279,24 +589,21 @@ func (d *Deployment) GetGeneration() int {
 	return d.Metadata.Generation
 }
 
-// GetSelector return slice of selectors associated with Deployment
-func (d *Deployment) GetSelector() []string {
-	selectorList := make([]string, 0)
-	for key, value := range d.Metadata.Labels {
-		selectorList = append(selectorList, fmt.Sprintf("%s=%s", key, value))
-	}
+// GetRevision return revision number assigned by Deployment controller, 0 if not assigned yet
+func (d *Deployment) GetRevision() int {
+	revision, _ := strconv.Atoi(d.Metadata.Annotations[AnnotationRevision])
+	return revision
+}
 
-	return selectorList
+// GetSelector return slice of selectors associated with Deployment (spec.selector)
+func (d *Deployment) GetSelector() []string {
+	return d.Spec.getSelector()
 }
 
-// GetPodSelector return slice of selectors associated with Deployment Spec template
+// GetPodSelector return slice of selectors matching pods of Deployment,
+// which is the same as spec.selector
 func (d *Deployment) GetPodSelector() []string {
-	selectorList := make([]string, 0)
-	for key, value := range d.Spec.Template.Metadata.Labels {
-		selectorList = append(selectorList, fmt.Sprintf("%s=%s", key, value))
-	}
-
-	return selectorList
+	return d.Spec.getSelector()
 }
 
 // ToDeployment interface method
@@ -304,6 +611,11 @@ func (d *Deployment) ToDeployment() (*Deployment, error) {
 	return d, nil
 }
 
+// GetImages return list of docker images registered in Deployment pod template, init containers included
+func (d *Deployment) GetImages() []string {
+	return d.Spec.Template.Spec.getImages()
+}
+
 // IsReady check deploy has been rolled out
 // @see https://kubernetes.io/docs/user-guide/deployments/#the-status-of-a-deployment
 func (d *Deployment) IsReady() bool {
@@ -312,16 +624,66 @@ func (d *Deployment) IsReady() bool {
 	isReady = isReady && (d.Status.UnavailableReplicas == 0)
 
 	if (d.Spec.Replicas != 0) && (d.Spec.Strategy.Type == strategyTypeRollingUpdate) {
-		replicaMinRequired := d.Spec.Replicas - d.Spec.Strategy.RollingUpdate.MaxUnavailable
+		_, maxUnavailable := d.GetRollingParameters()
+		replicaMinRequired := d.Spec.Replicas - maxUnavailable
 		isReady = isReady && (d.Status.AvailableReplicas >= replicaMinRequired)
 	}
 
 	return isReady
 }
 
+// GetRollingParameters return absolute maxSurge and maxUnavailable values,
+// percentage of surge is rounded up, percentage of unavailable is rounded down
+// @see https://kubernetes.io/docs/concepts/workloads/controllers/deployment/#rolling-update-deployment
+func (d *Deployment) GetRollingParameters() (int, int) {
+	rolling := d.Spec.Strategy.RollingUpdate
+
+	// invalid values are rejected by API server, so treat them as zero
+	maxSurge, _ := rolling.MaxSurge.GetScaledValue(d.Spec.Replicas, true)
+	maxUnavailable, _ := rolling.MaxUnavailable.GetScaledValue(d.Spec.Replicas, false)
+
+	// both values can't be zero, otherwise rollout will never progress
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxUnavailable = 1
+	}
+
+	return maxSurge, maxUnavailable
+}
+
+// GetCondition return Deployment condition of given type, nil if condition is not reported
+func (d *Deployment) GetCondition(conditionType string) *Condition {
+	return findCondition(d.Status.Conditions, conditionType)
+}
+
+// IsFailed check deployment rollout will never succeed without intervention
+func (d *Deployment) IsFailed() bool {
+	return d.GetFailureReason() != ""
+}
+
+// GetFailureReason return reason why deployment rollout is stuck or empty string,
+// conditions are not trusted until controller observed current generation
+// @see https://kubernetes.io/docs/concepts/workloads/controllers/deployment/#failed-deployment
+func (d *Deployment) GetFailureReason() string {
+	if d.Status.ObservedGeneration < d.Metadata.Generation {
+		return ""
+	}
+
+	c := d.GetCondition(DeploymentConditionProgressing)
+	if c != nil && c.Status == ConditionFalse && c.Reason == DeploymentReasonProgressDeadlineExceeded {
+		return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+	}
+
+	c = d.GetCondition(DeploymentConditionReplicaFailure)
+	if c != nil && c.Status == ConditionTrue {
+		return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+	}
+
+	return ""
+}
+
 // GetStatusString return deployment status message
 func (d *Deployment) GetStatusString() string {
-	return fmt.Sprintf(
+	status := fmt.Sprintf(
 		"Ready: %v, Generation: meta=%d observed=%d, Replicas: s=%d, u=%d, a=%d, na=%d",
 		d.IsReady(),
 		d.Metadata.Generation,
@@ -331,6 +693,13 @@ func (d *Deployment) GetStatusString() string {
 		d.Status.AvailableReplicas,
 		d.Status.UnavailableReplicas,
 	)
+
+	if d.Spec.Strategy.Type == strategyTypeRollingUpdate {
+		maxSurge, maxUnavailable := d.GetRollingParameters()
+		status += fmt.Sprintf(", Rolling: ms=%d, mu=%d", maxSurge, maxUnavailable)
+	}
+
+	return status
 }
 
 // GetItems is an interface support method
@@ -352,13 +721,20 @@ func (r *ReplicaSet) GetName() string {
 	return r.Metadata.Name
 }
 
-// GetImages return list of docker images registered in ReplicaSet
+// GetImages return list of docker images registered in ReplicaSet, init containers included
 func (r *ReplicaSet) GetImages() []string {
-	items := make([]string, 0)
-	for _, c := range r.Spec.Template.Spec.Containers {
-		items = append(items, c.Image)
-	}
-	return items
+	return r.Spec.Template.Spec.getImages()
+}
+
+// GetRevision return revision number of ReplicaSet assigned by Deployment controller
+func (r *ReplicaSet) GetRevision() int {
+	revision, _ := strconv.Atoi(r.Metadata.Annotations[AnnotationRevision])
+	return revision
+}
+
+// GetPodTemplateHash return hash which every pod of ReplicaSet is labeled with
+func (r *ReplicaSet) GetPodTemplateHash() string {
+	return r.Metadata.Labels[LabelPodTemplateHash]
 }
 
 // ToDeployment interface method
@@ -390,6 +766,51 @@ func (n *Namespace) ToDeployment() (*Deployment, error) {
 	return nil, errors.New("Namespace can't be transformed to deployment")
 }
 
+// GetKind interface method support, returns lowercase kind
+func (r *Resource) GetKind() string {
+	return strings.ToLower(r.Kind)
+}
+
+// GetName return name of resource
+func (r *Resource) GetName() string {
+	return r.Metadata.Name
+}
+
+// GetNamespace return namespace of resource
+func (r *Resource) GetNamespace() string {
+	return formatNamespace(r.Metadata.Namespace)
+}
+
+// GetKey return namespace/name of resource
+func (r *Resource) GetKey() string {
+	return fmt.Sprintf("%s/%s", r.GetNamespace(), r.GetName())
+}
+
+// GetImages return images of containers and init containers of resource pod spec, wherever it's defined
+func (r *Resource) GetImages() ([]string, error) {
+	workload := &struct {
+		Spec resourceWorkloadSpec `yaml:"spec"`
+	}{}
+	if err := yaml.Unmarshal(r.Manifest, workload); err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0)
+	for _, spec := range []resourceContainerSpec{
+		{Containers: workload.Spec.Containers, InitContainers: workload.Spec.InitContainers},
+		workload.Spec.Template.Spec,
+		workload.Spec.JobTemplate.Spec.Template.Spec,
+	} {
+		items = append(items, spec.getImages()...)
+	}
+	return items, nil
+}
+
+// ToDeployment interface method
+func (r *Resource) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("Resource can't be transformed to deployment")
+}
+
 // GetItems is an interface support method
 func (nl *namespaceList) GetItems() ResourceList {
 	r := make([]KubeResourceInterface, 0)
@@ -419,11 +840,61 @@ func (p *Pod) GetKey() string {
 	return fmt.Sprintf("%s/%s", p.GetNamespace(), p.GetName())
 }
 
+// GetFailureReason return reason why pod will never become ready without intervention,
+// empty string is returned for healthy pod. Restart count check is disabled when maxRestarts is 0.
+func (p *Pod) GetFailureReason(maxRestarts int) string {
+	for _, c := range p.Status.Conditions {
+		if c.Type == PodConditionScheduled && c.Status == ConditionFalse && c.Reason == PodReasonUnschedulable {
+			return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		}
+	}
+
+	// failing init container keeps pod pending forever
+	for _, cs := range append(p.Status.InitContainerStatuses, p.Status.ContainerStatuses...) {
+		if cs.State.Waiting != nil {
+			for _, reason := range fatalWaitingReasonList {
+				if cs.State.Waiting.Reason == reason {
+					return fmt.Sprintf("container %s: %s", cs.Name, reason)
+				}
+			}
+		}
+
+		if maxRestarts > 0 && cs.RestartCount > maxRestarts {
+			return fmt.Sprintf("container %s: restarted %d times", cs.Name, cs.RestartCount)
+		}
+	}
+
+	return ""
+}
+
 // ToDeployment interface method
 func (p *Pod) ToDeployment() (*Deployment, error) {
 	return nil, errors.New("Pod can't be transformed to deployment")
 }
 
+// GetImages return list of docker images defined in Pod spec, init containers included
+func (p *Pod) GetImages() []string {
+	return p.Spec.getImages()
+}
+
+// GetImageIDs return repository digest references of images containers are actually running,
+// e.g. example.com:80/dalee/image@sha256:ffff..., containers not started yet are skipped
+func (p *Pod) GetImageIDs() []string {
+	items := make([]string, 0)
+	for _, cs := range append(p.Status.InitContainerStatuses, p.Status.ContainerStatuses...) {
+		imageID := cs.ImageID
+		if i := strings.Index(imageID, "://"); i >= 0 {
+			imageID = imageID[i+3:]
+		}
+
+		// local image id (sha256:ffff...) has no repository
+		if strings.Contains(imageID, "@") {
+			items = append(items, imageID)
+		}
+	}
+	return items
+}
+
 // GetItems interface method
 func (pl *podList) GetItems() ResourceList {
 	r := make([]KubeResourceInterface, 0)
@@ -432,3 +903,355 @@ func (pl *podList) GetItems() ResourceList {
 	}
 	return r
 }
+
+// GetKind interface method support, returns string "statefulset"
+func (s *StatefulSet) GetKind() string {
+	return strings.ToLower(s.Kind)
+}
+
+// GetName return name of StatefulSet
+func (s *StatefulSet) GetName() string {
+	return s.Metadata.Name
+}
+
+// GetNamespace return StatefulSet namespace
+func (s *StatefulSet) GetNamespace() string {
+	return formatNamespace(s.Metadata.Namespace)
+}
+
+// GetKey will return unique name within a cluster
+func (s *StatefulSet) GetKey() string {
+	return fmt.Sprintf("%s/%s", s.GetNamespace(), s.GetName())
+}
+
+// GetSelector return slice of selectors associated with StatefulSet (spec.selector)
+func (s *StatefulSet) GetSelector() []string {
+	return s.Spec.getSelector()
+}
+
+// GetPodSelector return slice of selectors matching pods of StatefulSet,
+// which is the same as spec.selector
+func (s *StatefulSet) GetPodSelector() []string {
+	return s.Spec.getSelector()
+}
+
+// ToDeployment interface method
+func (s *StatefulSet) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("StatefulSet can't be transformed to deployment")
+}
+
+// GetImages return list of docker images registered in StatefulSet pod template, init containers included
+func (s *StatefulSet) GetImages() []string {
+	return s.Spec.Template.Spec.getImages()
+}
+
+// IsReady check StatefulSet has been rolled out, same rules as "kubectl rollout status" uses
+func (s *StatefulSet) IsReady() bool {
+	isReady := s.Status.ObservedGeneration >= s.Metadata.Generation
+	if s.Spec.UpdateStrategy.Type != strategyTypeRollingUpdate {
+		return isReady // OnDelete strategy, nothing to wait for
+	}
+
+	isReady = isReady && (s.Status.ReadyReplicas >= s.Spec.Replicas)
+
+	// partitioned rollout, only pods with ordinal >= partition are updated
+	if partition := s.Spec.UpdateStrategy.RollingUpdate.Partition; partition > 0 {
+		return isReady && (s.Status.UpdatedReplicas >= s.Spec.Replicas-partition)
+	}
+
+	isReady = isReady && (s.Status.UpdateRevision == s.Status.CurrentRevision)
+
+	return isReady
+}
+
+// GetStatusString return StatefulSet status message
+func (s *StatefulSet) GetStatusString() string {
+	return fmt.Sprintf(
+		"Ready: %v, Generation: meta=%d observed=%d, Replicas: s=%d, u=%d, r=%d, Revision: current=%s update=%s",
+		s.IsReady(),
+		s.Metadata.Generation,
+		s.Status.ObservedGeneration,
+		s.Spec.Replicas,
+		s.Status.UpdatedReplicas,
+		s.Status.ReadyReplicas,
+		s.Status.CurrentRevision,
+		s.Status.UpdateRevision,
+	)
+}
+
+// GetItems is an interface support method
+func (sl *statefulSetList) GetItems() ResourceList {
+	r := make([]KubeResourceInterface, 0)
+	for i := range sl.Items {
+		r = append(r, &sl.Items[i])
+	}
+	return r
+}
+
+// GetKind interface method support, returns string "daemonset"
+func (d *DaemonSet) GetKind() string {
+	return strings.ToLower(d.Kind)
+}
+
+// GetName return name of DaemonSet
+func (d *DaemonSet) GetName() string {
+	return d.Metadata.Name
+}
+
+// GetNamespace return DaemonSet namespace
+func (d *DaemonSet) GetNamespace() string {
+	return formatNamespace(d.Metadata.Namespace)
+}
+
+// GetKey will return unique name within a cluster
+func (d *DaemonSet) GetKey() string {
+	return fmt.Sprintf("%s/%s", d.GetNamespace(), d.GetName())
+}
+
+// GetSelector return slice of selectors associated with DaemonSet (spec.selector)
+func (d *DaemonSet) GetSelector() []string {
+	return d.Spec.getSelector()
+}
+
+// GetPodSelector return slice of selectors matching pods of DaemonSet,
+// which is the same as spec.selector
+func (d *DaemonSet) GetPodSelector() []string {
+	return d.Spec.getSelector()
+}
+
+// ToDeployment interface method
+func (d *DaemonSet) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("DaemonSet can't be transformed to deployment")
+}
+
+// GetImages return list of docker images registered in DaemonSet pod template, init containers included
+func (d *DaemonSet) GetImages() []string {
+	return d.Spec.Template.Spec.getImages()
+}
+
+// IsReady check DaemonSet has been rolled out, same rules as "kubectl rollout status" uses
+func (d *DaemonSet) IsReady() bool {
+	isReady := d.Status.ObservedGeneration >= d.Metadata.Generation
+	if d.Spec.UpdateStrategy.Type != strategyTypeRollingUpdate {
+		return isReady // OnDelete strategy, nothing to wait for
+	}
+
+	isReady = isReady && (d.Status.UpdatedNumberScheduled >= d.Status.DesiredNumberScheduled)
+	isReady = isReady && (d.Status.NumberReady >= d.Status.DesiredNumberScheduled)
+	isReady = isReady && (d.Status.NumberUnavailable == 0)
+
+	return isReady
+}
+
+// GetStatusString return DaemonSet status message
+func (d *DaemonSet) GetStatusString() string {
+	return fmt.Sprintf(
+		"Ready: %v, Generation: meta=%d observed=%d, Scheduled: d=%d, u=%d, r=%d, na=%d",
+		d.IsReady(),
+		d.Metadata.Generation,
+		d.Status.ObservedGeneration,
+		d.Status.DesiredNumberScheduled,
+		d.Status.UpdatedNumberScheduled,
+		d.Status.NumberReady,
+		d.Status.NumberUnavailable,
+	)
+}
+
+// GetItems is an interface support method
+func (dl *daemonSetList) GetItems() ResourceList {
+	r := make([]KubeResourceInterface, 0)
+	for i := range dl.Items {
+		r = append(r, &dl.Items[i])
+	}
+	return r
+}
+
+// GetKind interface method support, returns string "job"
+func (j *Job) GetKind() string {
+	return strings.ToLower(j.Kind)
+}
+
+// GetName return name of Job
+func (j *Job) GetName() string {
+	return j.Metadata.Name
+}
+
+// GetNamespace return Job namespace
+func (j *Job) GetNamespace() string {
+	return formatNamespace(j.Metadata.Namespace)
+}
+
+// GetKey will return unique name within a cluster
+func (j *Job) GetKey() string {
+	return fmt.Sprintf("%s/%s", j.GetNamespace(), j.GetName())
+}
+
+// GetSelector return slice of selectors associated with Job
+func (j *Job) GetSelector() []string {
+	return j.GetPodSelector()
+}
+
+// GetPodSelector return selector of pods created by Job, spec.selector is generated
+// by Job controller, if it's not known yet, job name label is used
+func (j *Job) GetPodSelector() []string {
+	if !j.Spec.Selector.IsEmpty() {
+		return j.Spec.Selector.GetSelector()
+	}
+	return []string{fmt.Sprintf("job-name=%s", j.GetName())}
+}
+
+// ToDeployment interface method
+func (j *Job) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("Job can't be transformed to deployment")
+}
+
+// GetImages return list of docker images registered in Job pod template, init containers included
+func (j *Job) GetImages() []string {
+	return j.Spec.Template.Spec.getImages()
+}
+
+// IsReady check Job has been completed successfully
+func (j *Job) IsReady() bool {
+	if c := findCondition(j.Status.Conditions, JobConditionComplete); c != nil && c.Status == ConditionTrue {
+		return true
+	}
+
+	completions := j.Spec.Completions
+	if completions == 0 {
+		completions = 1 // kubernetes default
+	}
+
+	return j.Status.Succeeded >= completions
+}
+
+// IsFailed check Job has been failed, e.g. backoff limit or deadline is reached
+func (j *Job) IsFailed() bool {
+	return j.GetFailureReason() != ""
+}
+
+// GetFailureReason return reason of Job failure or empty string
+func (j *Job) GetFailureReason() string {
+	if c := findCondition(j.Status.Conditions, JobConditionFailed); c != nil && c.Status == ConditionTrue {
+		return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+	}
+	return ""
+}
+
+// GetStatusString return Job status message
+func (j *Job) GetStatusString() string {
+	return fmt.Sprintf(
+		"Ready: %v, Failed: %v, Pods: a=%d, s=%d, f=%d",
+		j.IsReady(),
+		j.IsFailed(),
+		j.Status.Active,
+		j.Status.Succeeded,
+		j.Status.Failed,
+	)
+}
+
+// GetItems is an interface support method
+func (jl *jobList) GetItems() ResourceList {
+	r := make([]KubeResourceInterface, 0)
+	for i := range jl.Items {
+		r = append(r, &jl.Items[i])
+	}
+	return r
+}
+
+// GetKind interface method support, returns string "cronjob"
+func (c *CronJob) GetKind() string {
+	return strings.ToLower(c.Kind)
+}
+
+// GetName return name of CronJob
+func (c *CronJob) GetName() string {
+	return c.Metadata.Name
+}
+
+// GetNamespace return CronJob namespace
+func (c *CronJob) GetNamespace() string {
+	return formatNamespace(c.Metadata.Namespace)
+}
+
+// GetKey will return unique name within a cluster
+func (c *CronJob) GetKey() string {
+	return fmt.Sprintf("%s/%s", c.GetNamespace(), c.GetName())
+}
+
+// GetImages return list of docker images registered in CronJob job template, init containers included
+func (c *CronJob) GetImages() []string {
+	return c.Spec.JobTemplate.Spec.Template.Spec.getImages()
+}
+
+// ToDeployment interface method
+func (c *CronJob) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("CronJob can't be transformed to deployment")
+}
+
+// GetItems is an interface support method
+func (cl *cronJobList) GetItems() ResourceList {
+	r := make([]KubeResourceInterface, 0)
+	for i := range cl.Items {
+		r = append(r, &cl.Items[i])
+	}
+	return r
+}
+
+// GetRevisionHash return hash which every pod of revision is labeled with
+func (c *ControllerRevision) GetRevisionHash() string {
+	return c.Metadata.Labels[LabelControllerRevisionHash]
+}
+
+// GetKind interface method support, returns string "controllerrevision"
+func (c *ControllerRevision) GetKind() string {
+	return strings.ToLower(c.Kind)
+}
+
+// GetName return name of ControllerRevision
+func (c *ControllerRevision) GetName() string {
+	return c.Metadata.Name
+}
+
+// ToDeployment interface method
+func (c *ControllerRevision) ToDeployment() (*Deployment, error) {
+	return nil, errors.New("ControllerRevision can't be transformed to deployment")
+}
+
+// GetItems interface method
+func (cl *controllerRevisionList) GetItems() ResourceList {
+	r := make([]KubeResourceInterface, 0)
+	for i := range cl.Items {
+		r = append(r, &cl.Items[i])
+	}
+	return r
+}
+
+// images of init containers followed by images of containers
+func (s resourceContainerSpec) getImages() []string {
+	items := make([]string, 0)
+	for _, c := range append(s.InitContainers, s.Containers...) {
+		if c.Image != "" {
+			items = append(items, c.Image)
+		}
+	}
+	return items
+}
+
+// find condition of given type in condition list
+func findCondition(conditionList []Condition, conditionType string) *Condition {
+	for i := range conditionList {
+		if conditionList[i].Type == conditionType {
+			return &conditionList[i]
+		}
+	}
+	return nil
+}
+
+// return selector defined in spec.selector, if selector is omitted,
+// Kubernetes defaults it to pod template labels
+func (s *resourceSpec) getSelector() []string {
+	if !s.Selector.IsEmpty() {
+		return s.Selector.GetSelector()
+	}
+	return labelsToSelector(s.Template.Metadata.Labels)
+}
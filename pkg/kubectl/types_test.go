@@ -83,6 +83,11 @@ func TestDeployment_Type(t *testing.T) {
 		},
 		Spec: resourceSpec{
 			Replicas: 1,
+			Selector: LabelSelector{
+				MatchLabels: map[string]string{
+					"project": "test-project-2",
+				},
+			},
 			Template: resourceTemplate{
 				Metadata: resourceMetadataSpec{
 					Labels: map[string]string{
@@ -94,7 +99,7 @@ func TestDeployment_Type(t *testing.T) {
 			Strategy: resourceStrategy{
 				Type: strategyTypeRollingUpdate,
 				RollingUpdate: resourceStrategyRolling{
-					MaxUnavailable: 0,
+					MaxUnavailable: IntOrStringFromInt(0),
 				},
 			},
 		},
@@ -108,10 +113,14 @@ func TestDeployment_Type(t *testing.T) {
 	assert.Equal(t, "test-deployment", d.GetName())
 	assert.Equal(t, "3eb259fc-bc6f-11e6-a342-005056ba5444", d.GetUUID())
 	assert.Equal(t, "default/test-deployment", d.GetKey())
-	assert.True(t, CompareStringSlices([]string{"sample-label1=example1"}, d.GetSelector()))
-	assert.True(t, CompareStringSlices([]string{"project=test-project-2", "project_build=12"}, d.GetPodSelector()))
+	assert.True(t, CompareStringSlices([]string{"project=test-project-2"}, d.GetSelector()))
+	assert.True(t, CompareStringSlices([]string{"project=test-project-2"}, d.GetPodSelector()))
+
+	// selector defaults to pod template labels
+	d.Spec.Selector = LabelSelector{}
+	assert.True(t, CompareStringSlices([]string{"project=test-project-2", "project_build=12"}, d.GetSelector()))
 	assert.False(t, d.IsReady())
-	assert.Equal(t, "Ready: false, Generation: meta=12 observed=0, Replicas: s=1, u=0, a=0, na=1", d.GetStatusString())
+	assert.Equal(t, "Ready: false, Generation: meta=12 observed=0, Replicas: s=1, u=0, a=0, na=1, Rolling: ms=0, mu=1", d.GetStatusString())
 
 	converted, err := d.ToDeployment()
 	assert.Nil(t, err)
@@ -203,7 +212,7 @@ func TestDeployment_IsReady(t *testing.T) {
 			Strategy: resourceStrategy{
 				Type: strategyTypeRollingUpdate,
 				RollingUpdate: resourceStrategyRolling{
-					MaxUnavailable: 0,
+					MaxUnavailable: IntOrStringFromInt(0),
 				},
 			},
 		},
@@ -217,7 +226,7 @@ func TestDeployment_IsReady(t *testing.T) {
 
 	// initial check
 	assert.False(t, d.IsReady())
-	assert.Equal(t, "Ready: false, Generation: meta=12 observed=11, Replicas: s=1, u=0, a=0, na=0", d.GetStatusString())
+	assert.Equal(t, "Ready: false, Generation: meta=12 observed=11, Replicas: s=1, u=0, a=0, na=0, Rolling: ms=0, mu=1", d.GetStatusString())
 	assert.Empty(t, d.GetPodSelector()) // since no labels defined in Spec.Metadata
 
 	// 1) k8s started rollout
@@ -226,22 +235,362 @@ func TestDeployment_IsReady(t *testing.T) {
 	d.Status.AvailableReplicas = 0
 	d.Status.UnavailableReplicas = 1
 	assert.False(t, d.IsReady())
-	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=0, a=0, na=1", d.GetStatusString())
+	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=0, a=0, na=1, Rolling: ms=0, mu=1", d.GetStatusString())
 
 	// 2) k8s changed updated replica count
 	d.Status.UpdatedReplicas = 1
 	assert.False(t, d.IsReady())
-	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=0, na=1", d.GetStatusString())
+	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=0, na=1, Rolling: ms=0, mu=1", d.GetStatusString())
 
 	// 3) k8s changed available replica count
 	d.Status.AvailableReplicas = 1
 	assert.False(t, d.IsReady())
-	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=1, na=1", d.GetStatusString())
+	assert.Equal(t, "Ready: false, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=1, na=1, Rolling: ms=0, mu=1", d.GetStatusString())
 
 	// 4) finally, k8s changed unavailable replica count
 	d.Status.UnavailableReplicas = 0
 	assert.True(t, d.IsReady())
-	assert.Equal(t, "Ready: true, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=1, na=0", d.GetStatusString())
+	assert.Equal(t, "Ready: true, Generation: meta=12 observed=12, Replicas: s=1, u=1, a=1, na=0, Rolling: ms=0, mu=1", d.GetStatusString())
 
 	// 5) rollout done..
 }
+
+func TestStatefulSet_IsReady(t *testing.T) {
+	s := StatefulSet{
+		Kind: "StatefulSet",
+		Metadata: resourceMetadata{
+			Name:       "test-statefulset",
+			Generation: 3,
+		},
+		Spec: resourceSpec{
+			Replicas: 2,
+			UpdateStrategy: resourceStrategy{
+				Type: strategyTypeRollingUpdate,
+			},
+		},
+		Status: resourceStatus{
+			ObservedGeneration: 3,
+			ReadyReplicas:      2,
+			UpdatedReplicas:    1,
+			CurrentRevision:    "test-statefulset-1",
+			UpdateRevision:     "test-statefulset-2",
+		},
+	}
+
+	assert.Equal(t, KindStatefulSet, s.GetKind())
+	assert.Equal(t, "default/test-statefulset", s.GetKey())
+	assert.False(t, s.IsReady())
+	assert.Equal(t, "Ready: false, Generation: meta=3 observed=3, Replicas: s=2, u=1, r=2, Revision: current=test-statefulset-1 update=test-statefulset-2", s.GetStatusString())
+
+	// partitioned rollout, only one pod should be updated
+	s.Spec.UpdateStrategy.RollingUpdate.Partition = 1
+	assert.True(t, s.IsReady())
+
+	// partitioned rollout, updated pod is not ready yet
+	s.Status.ReadyReplicas = 1
+	assert.False(t, s.IsReady())
+	s.Status.ReadyReplicas = 2
+
+	// rollout done
+	s.Spec.UpdateStrategy.RollingUpdate.Partition = 0
+	s.Status.UpdatedReplicas = 2
+	s.Status.CurrentRevision = "test-statefulset-2"
+	assert.True(t, s.IsReady())
+
+	_, err := s.ToDeployment()
+	assert.Error(t, err)
+}
+
+func TestDaemonSet_IsReady(t *testing.T) {
+	d := DaemonSet{
+		Kind: "DaemonSet",
+		Metadata: resourceMetadata{
+			Name:       "test-daemonset",
+			Namespace:  "kube-system",
+			Generation: 5,
+		},
+		Spec: resourceSpec{
+			UpdateStrategy: resourceStrategy{
+				Type: strategyTypeRollingUpdate,
+			},
+		},
+		Status: resourceStatus{
+			ObservedGeneration:     5,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberReady:            2,
+			NumberUnavailable:      1,
+		},
+	}
+
+	assert.Equal(t, KindDaemonSet, d.GetKind())
+	assert.Equal(t, "kube-system/test-daemonset", d.GetKey())
+	assert.False(t, d.IsReady())
+	assert.Equal(t, "Ready: false, Generation: meta=5 observed=5, Scheduled: d=3, u=3, r=2, na=1", d.GetStatusString())
+
+	d.Status.NumberReady = 3
+	d.Status.NumberUnavailable = 0
+	assert.True(t, d.IsReady())
+
+	// OnDelete strategy is ready as soon as generation is observed
+	d.Spec.UpdateStrategy.Type = "OnDelete"
+	d.Status.NumberReady = 0
+	assert.True(t, d.IsReady())
+
+	_, err := d.ToDeployment()
+	assert.Error(t, err)
+}
+
+func TestResourceList_ToRolloutList(t *testing.T) {
+	rl := ResourceList{
+		&Pod{Kind: "Pod"},
+		&Deployment{Kind: "Deployment"},
+		&StatefulSet{Kind: "StatefulSet"},
+		&DaemonSet{Kind: "DaemonSet"},
+		&ReplicaSet{Kind: "ReplicaSet"},
+	}
+
+	items := rl.ToRolloutList()
+	assert.Len(t, items, 3)
+	assert.Equal(t, KindDeployment, items[0].GetKind())
+	assert.Equal(t, KindStatefulSet, items[1].GetKind())
+	assert.Equal(t, KindDaemonSet, items[2].GetKind())
+}
+
+func TestJob_IsReady(t *testing.T) {
+	j := Job{
+		Kind: "Job",
+		Metadata: resourceMetadata{
+			Name: "db-migrate",
+		},
+		Status: resourceStatus{
+			Active: 1,
+		},
+	}
+
+	assert.Equal(t, KindJob, j.GetKind())
+	assert.Equal(t, "default/db-migrate", j.GetKey())
+	assert.Equal(t, []string{"job-name=db-migrate"}, j.GetPodSelector())
+	assert.False(t, j.IsReady())
+	assert.False(t, j.IsFailed())
+	assert.Equal(t, "Ready: false, Failed: false, Pods: a=1, s=0, f=0", j.GetStatusString())
+
+	// default completions is 1
+	j.Status.Active = 0
+	j.Status.Succeeded = 1
+	assert.True(t, j.IsReady())
+
+	// explicit completions
+	j.Spec.Completions = 2
+	assert.False(t, j.IsReady())
+
+	// backoff limit reached
+	j.Status.Failed = 6
+	j.Status.Conditions = []Condition{
+		{Type: JobConditionFailed, Status: ConditionTrue, Reason: "BackoffLimitExceeded"},
+	}
+	assert.False(t, j.IsReady())
+	assert.True(t, j.IsFailed())
+
+	_, err := j.ToDeployment()
+	assert.Error(t, err)
+}
+
+func TestReplicaSet_Revision(t *testing.T) {
+	rs := ReplicaSet{
+		Kind: "ReplicaSet",
+		Metadata: resourceMetadata{
+			Labels: map[string]string{
+				LabelPodTemplateHash: "1567981747",
+			},
+			Annotations: map[string]string{
+				AnnotationRevision: "12",
+			},
+		},
+	}
+
+	assert.Equal(t, 12, rs.GetRevision())
+	assert.Equal(t, "1567981747", rs.GetPodTemplateHash())
+
+	rs.Metadata.Annotations = nil
+	assert.Equal(t, 0, rs.GetRevision())
+}
+
+func TestPod_GetFailureReason(t *testing.T) {
+	p := Pod{
+		Kind: "Pod",
+		Status: resourceStatus{
+			Phase: PodStatusRunning,
+			ContainerStatuses: []resourceContainerStatus{
+				{Name: "app", Ready: true, RestartCount: 2},
+			},
+		},
+	}
+
+	assert.Equal(t, "", p.GetFailureReason(3))
+	assert.Equal(t, "container app: restarted 2 times", p.GetFailureReason(1))
+	assert.Equal(t, "", p.GetFailureReason(0))
+
+	p.Status.ContainerStatuses[0].State.Waiting = &resourceContainerStateWaiting{Reason: "ContainerCreating"}
+	assert.Equal(t, "", p.GetFailureReason(3))
+
+	p.Status.ContainerStatuses[0].State.Waiting = &resourceContainerStateWaiting{Reason: "ImagePullBackOff"}
+	assert.Equal(t, "container app: ImagePullBackOff", p.GetFailureReason(3))
+
+	p.Status.Phase = "Pending"
+	p.Status.ContainerStatuses = []resourceContainerStatus{
+		{Name: "app", State: resourceContainerState{Waiting: &resourceContainerStateWaiting{Reason: "PodInitializing"}}},
+	}
+	p.Status.InitContainerStatuses = []resourceContainerStatus{
+		{Name: "migrate", RestartCount: 5, State: resourceContainerState{Waiting: &resourceContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+	}
+	assert.Equal(t, "container migrate: CrashLoopBackOff", p.GetFailureReason(3))
+
+	p.Status.InitContainerStatuses = nil
+	p.Status.ContainerStatuses = nil
+	p.Status.Conditions = []Condition{
+		{
+			Type:    PodConditionScheduled,
+			Status:  ConditionFalse,
+			Reason:  PodReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient memory.",
+		},
+	}
+	assert.Equal(t, "Unschedulable: 0/3 nodes are available: 3 Insufficient memory.", p.GetFailureReason(3))
+}
+
+func TestDeployment_GetFailureReason(t *testing.T) {
+	d := Deployment{
+		Kind: "Deployment",
+		Metadata: resourceMetadata{
+			Name:       "test-deployment",
+			Generation: 4,
+		},
+		Spec: resourceSpec{
+			Replicas:                1,
+			ProgressDeadlineSeconds: 600,
+		},
+		Status: resourceStatus{
+			ObservedGeneration: 3,
+			Conditions: []Condition{
+				{
+					Type:    DeploymentConditionProgressing,
+					Status:  ConditionFalse,
+					Reason:  DeploymentReasonProgressDeadlineExceeded,
+					Message: "ReplicaSet \"test-deployment-1\" has timed out progressing.",
+				},
+			},
+		},
+	}
+
+	// condition is left from previous generation
+	assert.NotNil(t, d.GetCondition(DeploymentConditionProgressing))
+	assert.Nil(t, d.GetCondition(DeploymentConditionReplicaFailure))
+	assert.False(t, d.IsFailed())
+
+	// current generation observed
+	d.Status.ObservedGeneration = 4
+	assert.True(t, d.IsFailed())
+	assert.Equal(t, "ProgressDeadlineExceeded: ReplicaSet \"test-deployment-1\" has timed out progressing.", d.GetFailureReason())
+
+	// progressing normally, but pods can't be created
+	d.Status.Conditions = []Condition{
+		{Type: DeploymentConditionProgressing, Status: ConditionTrue, Reason: "ReplicaSetUpdated"},
+		{Type: DeploymentConditionReplicaFailure, Status: ConditionTrue, Reason: "FailedCreate", Message: "exceeded quota"},
+	}
+	assert.Equal(t, "FailedCreate: exceeded quota", d.GetFailureReason())
+
+	d.Status.Conditions = d.Status.Conditions[:1]
+	assert.False(t, d.IsFailed())
+}
+
+func TestDeployment_GetRollingParameters(t *testing.T) {
+	d := Deployment{
+		Kind: "Deployment",
+		Metadata: resourceMetadata{
+			Generation: 2,
+		},
+		Spec: resourceSpec{
+			Replicas: 10,
+			Strategy: resourceStrategy{
+				Type: strategyTypeRollingUpdate,
+				RollingUpdate: resourceStrategyRolling{
+					MaxSurge:       IntOrStringFromString("25%"),
+					MaxUnavailable: IntOrStringFromString("25%"),
+				},
+			},
+		},
+		Status: resourceStatus{
+			ObservedGeneration: 2,
+			UpdatedReplicas:    10,
+			AvailableReplicas:  8,
+		},
+	}
+
+	maxSurge, maxUnavailable := d.GetRollingParameters()
+	assert.Equal(t, 3, maxSurge)
+	assert.Equal(t, 2, maxUnavailable)
+	assert.True(t, d.IsReady())
+	assert.Equal(t, "Ready: true, Generation: meta=2 observed=2, Replicas: s=10, u=10, a=8, na=0, Rolling: ms=3, mu=2", d.GetStatusString())
+
+	d.Status.AvailableReplicas = 7
+	assert.False(t, d.IsReady())
+
+	// 5% of 10 replicas is rounded down to zero, fencepost makes it 1
+	d.Spec.Strategy.RollingUpdate.MaxSurge = IntOrStringFromInt(0)
+	d.Spec.Strategy.RollingUpdate.MaxUnavailable = IntOrStringFromString("5%")
+	maxSurge, maxUnavailable = d.GetRollingParameters()
+	assert.Equal(t, 0, maxSurge)
+	assert.Equal(t, 1, maxUnavailable)
+}
+
+func TestResource_GetImages(t *testing.T) {
+	resourceList, err := ParseResources([]byte(`kind: Deployment
+metadata:
+  name: backend
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: backend-migrate:1
+      containers:
+      - name: backend
+        image: backend:1
+---
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: cleanup:1
+---
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: debug
+    image: busybox:1
+---
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: not-an-image:1
+`))
+	assert.Nil(t, err)
+
+	imageList := make([]string, 0)
+	for _, r := range resourceList {
+		images, err := r.GetImages()
+		assert.Nil(t, err)
+		imageList = append(imageList, images...)
+	}
+	assert.Equal(t, []string{"backend-migrate:1", "backend:1", "cleanup:1", "busybox:1"}, imageList)
+}
@@ -0,0 +1,198 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const (
+	// WatchAdded is type of event of resource created, or existed when watch started
+	WatchAdded = "ADDED"
+
+	// WatchModified is type of event of changed resource
+	WatchModified = "MODIFIED"
+
+	// WatchDeleted is type of event of deleted resource, resource is in its last known state
+	WatchDeleted = "DELETED"
+
+	// watch event type of stream failure
+	watchEventError = "ERROR"
+)
+
+type (
+	// WatchEvent is a single change of resource
+	WatchEvent struct {
+		Type     string
+		Resource KubeResourceInterface
+	}
+
+	// ResourceWatch is a stream of resource changes, current state of every
+	// resource is sent first, channel is closed when stream is interrupted
+	ResourceWatch interface {
+		ResultChan() <-chan WatchEvent
+		Stop()
+	}
+
+	// JSON stream decoder of watch events, shared by kubectl and api backends
+	resourceWatch struct {
+		result chan WatchEvent
+		stop   chan struct{}
+		once   sync.Once
+		closer func()
+	}
+
+	// single API watch event
+	apiWatchEvent struct {
+		Type   string          `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+)
+
+// start decoding stream of watch events, kind is set to objects omitting it,
+// stream is stopped when context is done or when stream reports error
+func newResourceWatch(ctx context.Context, stream io.ReadCloser, kind string, closer func()) *resourceWatch {
+	w := &resourceWatch{
+		result: make(chan WatchEvent),
+		stop:   make(chan struct{}),
+		closer: closer,
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.Stop()
+		case <-w.stop:
+		}
+	}()
+
+	go func() {
+		defer close(w.result)
+		defer stream.Close()
+
+		p := newParser()
+		decoder := json.NewDecoder(stream)
+		for {
+			event := &apiWatchEvent{}
+			if err := decoder.Decode(event); err != nil || event.Type == watchEventError {
+				return
+			}
+
+			data, err := setObjectKind(event.Object, kind)
+			if err != nil {
+				return
+			}
+
+			items, err := p.parseYaml(data)
+			if err != nil {
+				continue
+			}
+
+			for _, item := range items {
+				select {
+				case w.result <- WatchEvent{Type: event.Type, Resource: item}:
+				case <-w.stop:
+					return
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// objects in watch stream may omit kind
+func setObjectKind(data []byte, kind string) ([]byte, error) {
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if _, ok := object["kind"]; !ok {
+		object["kind"] = kind
+	}
+
+	return json.Marshal(object)
+}
+
+// ResultChan return channel of resource changes
+func (w *resourceWatch) ResultChan() <-chan WatchEvent {
+	return w.result
+}
+
+// Stop interrupt stream, safe to call multiple times
+func (w *resourceWatch) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+		if w.closer != nil {
+			w.closer()
+		}
+	})
+}
+
+// "kubectl get kind -w --output-watch-events -o json" prints every event as separate JSON document,
+// the same way API server streams them
+func newKubectlWatch(ctx context.Context, contextName, namespace, kind string) (ResourceWatch, error) {
+	c := newCommandWithBinary([]string{
+		fmt.Sprintf("--namespace=%s", formatNamespace(namespace)),
+		"get",
+		kind,
+		"--watch",
+		"--output-watch-events",
+		"-o",
+		"json",
+	}, "kubectl")
+	if contextName != "" {
+		setCommandContext(c, contextName)
+	}
+
+	cmd := c.getCommand()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	closer := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	return newResourceWatch(ctx, stdout, kind, closer), nil
+}
+
+// API watch stream, every event is {"type": "...", "object": {...}}
+func (c *apiClient) watch(ctx context.Context, namespace, kind string) (ResourceWatch, error) {
+	resource, err := c.findResource(ctx, "", kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("watch", "true")
+
+	request, err := c.newRequest(ctx, http.MethodGet, resource.path(namespace, ""), query, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, fmt.Errorf("GET %s failed: %s", request.URL.Path, response.Status)
+	}
+
+	closer := func() {
+		response.Body.Close()
+	}
+
+	return newResourceWatch(ctx, response.Body, kind, closer), nil
+}
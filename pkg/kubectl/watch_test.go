@@ -0,0 +1,81 @@
+package kubectl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func collectWatch(w ResourceWatch) ResourceList {
+	rlist := make(ResourceList, 0)
+	for event := range w.ResultChan() {
+		rlist = append(rlist, event.Resource)
+	}
+	return rlist
+}
+
+func TestResourceWatch_KubectlStream(t *testing.T) {
+	stream := ioutil.NopCloser(strings.NewReader(`{
+    "type": "ADDED",
+    "object": {"kind": "Deployment", "metadata": {"name": "first", "namespace": "default"}}
+}
+{
+    "type": "ADDED",
+    "object": {"kind": "Deployment", "metadata": {"name": "second", "namespace": "default"}}
+}`))
+
+	w := newResourceWatch(context.Background(), stream, KindDeployment, nil)
+	rlist := collectWatch(w)
+	w.Stop()
+
+	assert.Len(t, rlist, 2)
+	assert.Equal(t, "first", rlist[0].GetName())
+	assert.Equal(t, "second", rlist[1].GetName())
+	assert.Len(t, rlist.ToDeploymentList(), 2)
+}
+
+func TestResourceWatch_Deleted(t *testing.T) {
+	stream := ioutil.NopCloser(strings.NewReader(`{"type": "MODIFIED", "object": {"kind": "Deployment", "metadata": {"name": "first"}}}
+{"type": "DELETED", "object": {"kind": "Deployment", "metadata": {"name": "first"}}}`))
+
+	w := newResourceWatch(context.Background(), stream, KindDeployment, nil)
+	typeList := make([]string, 0)
+	for event := range w.ResultChan() {
+		typeList = append(typeList, event.Type)
+		assert.Equal(t, "first", event.Resource.GetName())
+	}
+	w.Stop()
+
+	assert.Equal(t, []string{WatchModified, WatchDeleted}, typeList)
+}
+
+func TestAPIClient_Watch(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{
+		"GET /api/v1/namespaces/default/pods": `{"type": "ADDED", "object": {"metadata": {"name": "pod-1"}, "status": {"phase": "Pending"}}}
+{"type": "MODIFIED", "object": {"kind": "Pod", "metadata": {"name": "pod-1"}, "status": {"phase": "Running"}}}
+{"type": "ERROR", "object": {"kind": "Status", "reason": "Expired"}}
+{"type": "ADDED", "object": {"kind": "Pod", "metadata": {"name": "pod-2"}}}`,
+	})
+	defer f.server.Close()
+
+	w, err := f.client().watch(context.Background(), "", KindPod)
+	assert.Nil(t, err)
+
+	plist := collectWatch(w).ToPodList()
+	w.Stop()
+
+	assert.Len(t, plist, 2)
+	assert.Equal(t, "Pending", plist[0].Status.Phase)
+	assert.Equal(t, PodStatusRunning, plist[1].Status.Phase)
+	assert.Equal(t, "/api/v1/namespaces/default/pods?watch=true", f.requests[0].uri)
+}
+
+func TestAPIClient_WatchUnknownKind(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{})
+	defer f.server.Close()
+
+	_, err := f.client().watch(context.Background(), "", "unknown")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,289 @@
+package kubectl
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// exec stream protocol, every message is prefixed with channel number
+	execProtocol = "v4.channel.k8s.io"
+
+	// exec stream channels
+	execChannelStdout = 1
+	execChannelStderr = 2
+	execChannelError  = 3
+
+	// websocket opcodes
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	// RFC 6455 handshake key suffix
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// execute command in pod container, stdout and stderr are returned as single output,
+// as "kubectl exec" prints them, non-zero exit code of command is an error
+func (c *apiClient) exec(ctx context.Context, namespace, pod, container, command string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("container", container)
+	query.Set("command", command)
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+
+	path := apiResourceList[KindPod].path(namespace, pod) + "/exec"
+	conn, reader, err := c.dialWebSocket(ctx, path, query, execProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// blocked read is interrupted by closed connection
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	output := make([]byte, 0)
+	for {
+		message, err := readWebSocketMessage(conn, reader)
+		if err == io.EOF {
+			return output, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return output, ctx.Err()
+			}
+			return output, err
+		}
+		if len(message) == 0 {
+			continue
+		}
+
+		switch message[0] {
+		case execChannelStdout, execChannelStderr:
+			output = append(output, message[1:]...)
+
+		case execChannelError:
+			status := &apiStatus{}
+			if err := json.Unmarshal(message[1:], status); err != nil {
+				return output, err
+			}
+			if status.Status != "Success" {
+				return output, errors.New(status.Message)
+			}
+			return output, nil
+		}
+	}
+}
+
+// open websocket connection to API server, connection is authorized the same way as regular requests
+func (c *apiClient) dialWebSocket(ctx context.Context, path string, query url.Values, protocol string) (net.Conn, *bufio.Reader, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyData := make([]byte, 16)
+	if _, err := rand.Read(keyData); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyData)
+
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Protocol", protocol)
+
+	conn, err := c.dial(ctx, request.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		data, _ := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		conn.Close()
+		return nil, nil, newAPIError(request.Method, path, response.Status, data)
+	}
+
+	accept := sha1.Sum([]byte(key + wsAcceptGUID))
+	if response.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		conn.Close()
+		return nil, nil, fmt.Errorf("GET %s failed: invalid websocket handshake", path)
+	}
+
+	return conn, reader, nil
+}
+
+// connect to API server, TLS configuration of client is used for https
+func (c *apiClient) dial(ctx context.Context, u *url.URL) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil || u.Scheme != "https" {
+		return conn, err
+	}
+
+	tlsConfig := &tls.Config{}
+	if c.tlsConfig != nil {
+		tlsConfig = c.tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// read next data message, fragmented messages are joined, pings are answered,
+// io.EOF is returned when server closes connection
+func readWebSocketMessage(conn net.Conn, reader *bufio.Reader) ([]byte, error) {
+	message := make([]byte, 0)
+	for {
+		fin, opcode, payload, err := readWebSocketFrame(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpClose:
+			return nil, io.EOF
+
+		case wsOpPing:
+			if err := writeWebSocketFrame(conn, wsOpPong, payload); err != nil {
+				return nil, err
+			}
+
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		}
+	}
+}
+
+// read single frame, frames sent by server are not masked, but mask is supported anyway
+func readWebSocketFrame(reader *bufio.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	isMasked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err = io.ReadFull(reader, extended); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err = io.ReadFull(reader, extended); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	mask := make([]byte, 4)
+	if isMasked {
+		if _, err = io.ReadFull(reader, mask); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(reader, payload); err != nil {
+		return
+	}
+	if isMasked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return
+}
+
+// write single final frame, frames sent by client must be masked
+func writeWebSocketFrame(w io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 0x80|126, byte(length>>8), byte(length))
+	default:
+		extended := make([]byte, 8)
+		binary.BigEndian.PutUint64(extended, uint64(length))
+		frame = append(frame, 0x80|127)
+		frame = append(frame, extended...)
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := w.Write(frame)
+	return err
+}
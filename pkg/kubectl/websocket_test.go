@@ -0,0 +1,104 @@
+package kubectl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unmasked frame, as sent by server
+func serverFrame(fin bool, opcode byte, payload []byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	return append([]byte{first, byte(len(payload))}, payload...)
+}
+
+// fake exec endpoint, frames are sent right after handshake
+func newFakeExecServer(t *testing.T, frames ...[]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/default/pods/pod-1/exec", r.URL.Path)
+		assert.Equal(t, "command=date&container=app&stderr=true&stdout=true", r.URL.RawQuery)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, execProtocol, r.Header.Get("Sec-WebSocket-Protocol"))
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsAcceptGUID))
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+		for _, frame := range frames {
+			rw.Write(frame)
+		}
+		rw.Flush()
+
+		// wait for pong, if ping was sent
+		rw.Read(make([]byte, 1))
+	}))
+}
+
+func TestAPICluster_Exec(t *testing.T) {
+	server := newFakeExecServer(t,
+		serverFrame(true, wsOpBinary, []byte{execChannelStdout}),
+		serverFrame(false, wsOpBinary, []byte("\x01Tue Jul ")),
+		serverFrame(true, wsOpPing, []byte("ping")),
+		serverFrame(true, wsOpContinuation, []byte("4 2017\n")),
+		serverFrame(true, wsOpBinary, []byte("\x02warning\n")),
+		serverFrame(true, wsOpBinary, append([]byte{execChannelError}, []byte(`{"metadata":{},"status":"Success"}`)...)),
+	)
+	defer server.Close()
+
+	cluster := &apiCluster{client: &apiClient{server: server.URL, token: "token", httpClient: http.DefaultClient}}
+	output, err := cluster.Exec(context.Background(), "default", "pod-1", "app", "date")
+	assert.Nil(t, err)
+	assert.Equal(t, "Tue Jul 4 2017\nwarning\n", string(output))
+}
+
+func TestAPICluster_ExecFailed(t *testing.T) {
+	server := newFakeExecServer(t,
+		serverFrame(true, wsOpBinary, []byte("\x02date: invalid option\n")),
+		serverFrame(true, wsOpBinary, append([]byte{execChannelError}, []byte(`{"status":"Failure","message":"command terminated with non-zero exit code"}`)...)),
+	)
+	defer server.Close()
+
+	cluster := &apiCluster{client: &apiClient{server: server.URL, token: "token", httpClient: http.DefaultClient}}
+	output, err := cluster.Exec(context.Background(), "default", "pod-1", "app", "date")
+	assert.EqualError(t, err, "command terminated with non-zero exit code")
+	assert.Equal(t, "date: invalid option\n", string(output))
+}
+
+func TestAPICluster_ExecNotFound(t *testing.T) {
+	f := newFakeAPIServer(map[string]string{})
+	defer f.server.Close()
+
+	_, err := f.cluster().Exec(context.Background(), "default", "pod-1", "app", "date")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestWebSocketFrame_Masked(t *testing.T) {
+	payload := bytes.Repeat([]byte("fuse"), 100)
+	buffer := &bytes.Buffer{}
+	assert.Nil(t, writeWebSocketFrame(buffer, wsOpBinary, payload))
+
+	data := buffer.Bytes()
+	assert.Equal(t, byte(0x80|wsOpBinary), data[0])
+	assert.Equal(t, byte(0x80|126), data[1])
+
+	fin, opcode, decoded, err := readWebSocketFrame(bufio.NewReader(buffer))
+	assert.Nil(t, err)
+	assert.True(t, fin)
+	assert.Equal(t, byte(wsOpBinary), opcode)
+	assert.Equal(t, payload, decoded)
+}
@@ -6,12 +6,30 @@ import (
 	"strings"
 )
 
+const (
+	// DefaultRegistry is registry of references without explicit registry
+	DefaultRegistry = "docker.io"
+
+	// DefaultTag is tag of references without tag and digest
+	DefaultTag = "latest"
+
+	// legacy name of default registry
+	legacyDefaultRegistry = "index.docker.io"
+
+	// path prefix of official images hosted by default registry
+	officialPathPrefix = "library/"
+
+	// maximum length of registry and path together
+	nameMaxLength = 255
+)
+
 type (
 	// ImageReference is parsed image reference specification
 	ImageReference struct {
-		Repository  string
-		Tag         string
-		RegistryURL string
+		Registry string // "" for implicit default registry
+		Path     string
+		Tag      string // "" for references with digest only
+		Digest   string
 	}
 )
 
@@ -19,49 +37,147 @@ var (
 	// ErrReferenceInvalidFormat is thrown when unable to parse image reference
 	ErrReferenceInvalidFormat = errors.New("Invalid repository format")
 
-	// Set of RegExp to decode Docker image reference
-	tagRe    = regexp.MustCompile(`^:([a-z0-9._-]+)`)
-	domainRe = regexp.MustCompile(`^(([a-z0-9-_]+)(\.[a-z0-9-_]+)*(:[0-9]+)?)`)
-	pathRe   = regexp.MustCompile(`^/?(([a-z0-9-_.]+)(/[a-z0-9-_.]+)*)`)
+	// ErrTagInvalidFormat is thrown when tag of reference is invalid
+	ErrTagInvalidFormat = errors.New("Invalid tag format")
+
+	// ErrDigestInvalidFormat is thrown when digest of reference is invalid
+	ErrDigestInvalidFormat = errors.New("Invalid digest format")
+
+	// ErrNameContainsUppercase is thrown when repository path contains uppercase characters
+	ErrNameContainsUppercase = errors.New("Repository name must be lowercase")
+
+	// ErrNameEmpty is thrown when reference has no repository name
+	ErrNameEmpty = errors.New("Repository name must have at least one component")
+
+	// ErrNameTooLong is thrown when repository name is longer than 255 characters
+	ErrNameTooLong = errors.New("Repository name must not be more than 255 characters")
+
+	// Set of RegExp to decode Docker image reference, as defined by Docker Distribution grammar
+	tagRe    = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+	domainRe = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	pathRe   = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*$`)
 )
 
 // DecodeReference will try to parse image reference and return following structure:
 //
 // for given input:
-// registry.example.com:80/sample/repository:42
+// registry.example.com:80/sample/repository:42@sha256:ffff...
 //
 // it will will ImageReference as follow:
-// Repository: sample/repository
+// Registry: registry.example.com:80
+// Path: sample/repository
 // Tag: 42
-// RegistryURL: registry.example.com:80
+// Digest: sha256:ffff...
 //
-// more examples in tests
+// registry is detected only if first path component contains "." or ":", or it's "localhost",
+// tag is "latest" if neither tag nor digest is provided, more examples in tests
 func DecodeReference(reference string) (*ImageReference, error) {
+	name := reference
 
-	registryURL := ""
-	if strings.Count(reference, ":") > 1 || strings.Count(reference, "/") > 0 {
-		registryURL = domainRe.FindString(reference)
-		reference = strings.Replace(reference, registryURL, "", 1)
+	digest := ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i+1:]
+		if !digestRe.MatchString(digest) {
+			return nil, ErrDigestInvalidFormat
+		}
 	}
 
-	repository := pathRe.FindString(reference)
-	reference = strings.Replace(reference, repository, "", 1)
-	repository = strings.TrimLeft(repository, "/")
-	if repository == "" {
-		return nil, ErrReferenceInvalidFormat
+	tag := ""
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+		if !tagRe.MatchString(tag) {
+			return nil, ErrTagInvalidFormat
+		}
 	}
 
-	tag := tagRe.FindString(reference)
-	tag = strings.TrimLeft(tag, ":")
-	if tag == "" {
+	if name == "" {
+		return nil, ErrNameEmpty
+	}
+	if len(name) > nameMaxLength {
+		return nil, ErrNameTooLong
+	}
+
+	registry, path := splitRegistry(name)
+	if registry != "" && !domainRe.MatchString(registry) {
+		return nil, ErrReferenceInvalidFormat
+	}
+	if !pathRe.MatchString(path) {
+		if pathRe.MatchString(strings.ToLower(path)) {
+			return nil, ErrNameContainsUppercase
+		}
 		return nil, ErrReferenceInvalidFormat
 	}
 
+	if tag == "" && digest == "" {
+		tag = DefaultTag
+	}
+
 	repo := &ImageReference{
-		Repository:  repository,
-		Tag:         tag,
-		RegistryURL: registryURL,
+		Registry: registry,
+		Path:     path,
+		Tag:      tag,
+		Digest:   digest,
 	}
 
 	return repo, nil
 }
+
+// NormalizeReference decode reference and make default registry and official image prefix explicit,
+// e.g. "nginx" -> "docker.io/library/nginx:latest"
+func NormalizeReference(reference string) (*ImageReference, error) {
+	repo, err := DecodeReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Normalized(), nil
+}
+
+// split name into registry and path, first component is registry only if it looks like a host
+func splitRegistry(name string) (string, string) {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "", name
+	}
+
+	candidate := name[:i]
+	if strings.ContainsAny(candidate, ".:") || candidate == "localhost" {
+		return candidate, name[i+1:]
+	}
+
+	return "", name
+}
+
+// Normalized return copy of reference with explicit default registry and official image prefix
+func (r *ImageReference) Normalized() *ImageReference {
+	normalized := *r
+	if normalized.Registry == "" || normalized.Registry == legacyDefaultRegistry {
+		normalized.Registry = DefaultRegistry
+	}
+	if normalized.Registry == DefaultRegistry && !strings.Contains(normalized.Path, "/") {
+		normalized.Path = officialPathPrefix + normalized.Path
+	}
+
+	return &normalized
+}
+
+// Name return repository name, registry and path
+func (r *ImageReference) Name() string {
+	if r.Registry == "" {
+		return r.Path
+	}
+	return r.Registry + "/" + r.Path
+}
+
+// String return reference, decoding it gives the same ImageReference
+func (r *ImageReference) String() string {
+	reference := r.Name()
+	if r.Tag != "" {
+		reference += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		reference += "@" + r.Digest
+	}
+	return reference
+}
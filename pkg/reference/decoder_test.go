@@ -2,6 +2,7 @@ package reference
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -10,79 +11,148 @@ func TestDecodeImage(t *testing.T) {
 	var err error
 
 	refTestCaseList := []struct {
-		input      string
-		err        error
-		repository string
-		tag        string
-		registry   string
+		input    string
+		err      error
+		path     string
+		tag      string
+		digest   string
+		registry string
 	}{
 		{
 			input: "test_com",
-			err:   ErrReferenceInvalidFormat,
+			path:  "test_com",
+			tag:   "latest",
 		},
 		{
-			input:      "very.long.domain.registry:8080/test.com/repo:tag",
-			repository: "test.com/repo",
-			tag:        "tag",
-			registry:   "very.long.domain.registry:8080",
+			input: "nginx",
+			path:  "nginx",
+			tag:   "latest",
 		},
 		{
-			input:      "example.com:5000/sample/unknown-repo:latest",
-			repository: "sample/unknown-repo",
-			tag:        "latest",
-			registry:   "example.com:5000",
+			input:    "very.long.domain.registry:8080/test.com/repo:tag",
+			path:     "test.com/repo",
+			tag:      "tag",
+			registry: "very.long.domain.registry:8080",
 		},
 		{
-			input:      "test.com:tag",
-			registry:   "",
-			repository: "test.com",
-			tag:        "tag",
+			input:    "example.com:5000/sample/unknown-repo:latest",
+			path:     "sample/unknown-repo",
+			tag:      "latest",
+			registry: "example.com:5000",
 		},
 		{
-			input:      "test.com:5000",
-			registry:   "",
-			repository: "test.com",
-			tag:        "5000",
+			input:    "test.com:tag",
+			registry: "",
+			path:     "test.com",
+			tag:      "tag",
 		},
 		{
-			input:      "test.com/repo:tag",
-			repository: "repo",
-			tag:        "tag",
-			registry:   "test.com",
+			input:    "test.com:5000",
+			registry: "",
+			path:     "test.com",
+			tag:      "5000",
 		},
 		{
-			input: "test:5000/repo",
-			err:   ErrReferenceInvalidFormat,
+			input:    "test.com/repo:tag",
+			path:     "repo",
+			tag:      "tag",
+			registry: "test.com",
+		},
+		{
+			input:    "test:5000/repo",
+			path:     "repo",
+			tag:      "latest",
+			registry: "test:5000",
+		},
+		{
+			input:    "test:5000/repo:tag",
+			path:     "repo",
+			tag:      "tag",
+			registry: "test:5000",
+		},
+		{
+			input:    "test:5000/repo:v1.2.3",
+			path:     "repo",
+			tag:      "v1.2.3",
+			registry: "test:5000",
+		},
+		{
+			input:    "test:5000/repo@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			path:     "repo",
+			digest:   "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			registry: "test:5000",
+		},
+		{
+			input:    "test:5000/repo:tag@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			path:     "repo",
+			tag:      "tag",
+			digest:   "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			registry: "test:5000",
+		},
+		{
+			input:    "localhost/repo:tag",
+			path:     "repo",
+			tag:      "tag",
+			registry: "localhost",
+		},
+		{
+			input:    "localhost:5000/sample/repo",
+			path:     "sample/repo",
+			tag:      "latest",
+			registry: "localhost:5000",
+		},
+		{
+			input: "sample/repo__name.v2-test:v_1",
+			path:  "sample/repo__name.v2-test",
+			tag:   "v_1",
+		},
+		{
+			input: "test:5000/repo:tag@sha256:fff",
+			err:   ErrDigestInvalidFormat,
+		},
+		{
+			input: "test:5000/repo@sha256",
+			err:   ErrDigestInvalidFormat,
 		},
 		{
-			input:      "test:5000/repo:tag",
-			repository: "repo",
-			tag:        "tag",
-			registry:   "test:5000",
+			input: "test:5000/repo:tag!",
+			err:   ErrTagInvalidFormat,
 		},
 		{
-			input:      "test:5000/repo:v1.2.3",
-			repository: "repo",
-			tag:        "v1.2.3",
-			registry:   "test:5000",
+			input: "test:5000/repo:-tag",
+			err:   ErrTagInvalidFormat,
 		},
 		{
-			input: "test:5000/repo@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			input: "test:5000/Sample/Repo:tag",
+			err:   ErrNameContainsUppercase,
+		},
+		{
+			input: "test:5000/repo-:tag",
 			err:   ErrReferenceInvalidFormat,
 		},
 		{
-			input:      "test:5000/repo:tag@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
-			repository: "repo",
-			tag:        "tag",
-			registry:   "test:5000",
+			input: "-example.com/repo:tag",
+			err:   ErrReferenceInvalidFormat,
 		},
 		{
-			input: ":justtag",
+			input: "test:5000//repo",
 			err:   ErrReferenceInvalidFormat,
 		},
+		{
+			input: "example.com/" + strings.Repeat("a", 256),
+			err:   ErrNameTooLong,
+		},
+		{
+			input: ":justtag",
+			err:   ErrNameEmpty,
+		},
 		{
 			input: "@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
-			err:   ErrReferenceInvalidFormat,
+			err:   ErrNameEmpty,
+		},
+		{
+			input: "",
+			err:   ErrNameEmpty,
 		},
 	}
 
@@ -96,12 +166,46 @@ func TestDecodeImage(t *testing.T) {
 			t.Fatal("Error is not nil and repo is not nil", err, repo)
 		}
 
-		if err == testCase.err {
+		if err == testCase.err && err != nil {
 			continue
 		}
 
-		assert.Equal(t, repo.Repository, testCase.repository)
-		assert.Equal(t, repo.Tag, testCase.tag)
-		assert.Equal(t, repo.RegistryURL, testCase.registry)
+		assert.Equal(t, testCase.path, repo.Path, testCase.input)
+		assert.Equal(t, testCase.tag, repo.Tag, testCase.input)
+		assert.Equal(t, testCase.digest, repo.Digest, testCase.input)
+		assert.Equal(t, testCase.registry, repo.Registry, testCase.input)
+
+		// round-trip
+		decoded, err := DecodeReference(repo.String())
+		assert.Nil(t, err)
+		assert.Equal(t, repo, decoded)
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	normalizeTestCaseList := map[string]string{
+		"nginx":                         "docker.io/library/nginx:latest",
+		"nginx:1.13":                    "docker.io/library/nginx:1.13",
+		"dalee/fuse":                    "docker.io/dalee/fuse:latest",
+		"index.docker.io/nginx":         "docker.io/library/nginx:latest",
+		"docker.io/library/nginx:1.13":  "docker.io/library/nginx:1.13",
+		"registry.example.com/backend":  "registry.example.com/backend:latest",
+		"localhost:5000/backend:v1.2.3": "localhost:5000/backend:v1.2.3",
 	}
+
+	for input, expected := range normalizeTestCaseList {
+		repo, err := NormalizeReference(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, repo.String(), input)
+	}
+
+	_, err := NormalizeReference("Nginx")
+	assert.Equal(t, ErrNameContainsUppercase, err)
+}
+
+func TestImageReference_Name(t *testing.T) {
+	repo, err := DecodeReference("registry.example.com:5000/sample/repo:1@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.example.com:5000/sample/repo", repo.Name())
+	assert.Equal(t, "docker.io/library/nginx", (&ImageReference{Path: "nginx"}).Normalized().Name())
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/Dalee/hitman/pkg/registry"
 	"strings"
+	"time"
 )
 
 type (
@@ -12,12 +13,22 @@ type (
 		GetImageDigestList(repo string) (*registry.RepositoryDigestList, error)
 	}
 
+	// registry interface extended with creation time of digest, required by retention policy
+	garbageRegistryInterface interface {
+		registryInterface
+		GetImageCreated(repo, digest string) (time.Time, error)
+	}
+
 	// GarbageDetectItem holds information about repository, deployed tags and garbage digests
 	GarbageDetectItem struct {
-		Repository        string
-		DeployedTagList   []string
-		GarbageDigestList []string
-		GarbageTagList    []string
+		Repository         string
+		DeployedTagList    []string
+		DeployedDigestList []string
+		GarbageDigestList  []string
+		GarbageTagList     []string
+		RetainedDigestList []string // not deployed, but kept by retention policy
+		RetainedTagList    []string
+		Policy             *RetentionPolicy // effective retention policy of repository
 	}
 
 	// GarbageDetectInfo holds whole list of GarbageDetectItem
@@ -36,6 +47,24 @@ func StringInSlice(s string, sl []string) bool {
 	return false
 }
 
+// FilterRegistryImages keeps images hosted by registry only, images of other registries
+// may have the same repository path, but they are never deployed from this registry
+func FilterRegistryImages(imageList []string, registryHost string) ([]string, error) {
+	filteredList := make([]string, 0)
+	for _, image := range imageList {
+		u, err := DecodeReference(image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", image, err)
+		}
+
+		if u.Registry == registryHost {
+			filteredList = append(filteredList, image)
+		}
+	}
+
+	return filteredList, nil
+}
+
 // RemoveDuplicates removes duplicated strings in slice (in-place)
 func RemoveDuplicates(xs *[]string) {
 	found := make(map[string]bool)
@@ -62,13 +91,19 @@ func SliceHasItemsInSlice(src, dst []string) bool {
 	return false
 }
 
-// DetectGarbage will detect garbage for a given set of deployed image references
-func DetectGarbage(k8sImageList []string, skipTags []string, api registryInterface, ignoreMissing bool) (*GarbageDetectInfo, error) {
+// DetectGarbage will detect garbage for a given set of deployed image references, policy is resolved
+// for every repository, digests which are not deployed, but kept by policy are reported as retained
+func DetectGarbage(k8sImageList []string, policySet PolicyInterface, api garbageRegistryInterface, ignoreMissing bool) (*GarbageDetectInfo, error) {
+	if policySet == nil {
+		policySet = &RetentionPolicy{}
+	}
+
 	// remove duplicated entries
 	RemoveDuplicates(&k8sImageList)
 
 	// prepare k8s deployed list and deployed repository list (to keep order, map order is not defined)
 	deployedImages := make(map[string][]string, 0)
+	deployedDigests := make(map[string][]string, 0)
 	deployedImagesList := make([]string, 0)
 
 	for _, imageRefSpec := range k8sImageList {
@@ -77,19 +112,26 @@ func DetectGarbage(k8sImageList []string, skipTags []string, api registryInterfa
 			return nil, err
 		}
 
-		deployedImages[u.Repository] =
-			append(deployedImages[u.Repository], u.Tag)
+		// images pinned to digest are deployed by digest, tag may be moved already
+		if u.Tag != "" {
+			deployedImages[u.Path] =
+				append(deployedImages[u.Path], u.Tag)
+		}
+		if u.Digest != "" {
+			deployedDigests[u.Path] =
+				append(deployedDigests[u.Path], u.Digest)
+		}
 
 		// if repository is not registered in orderList, register it
-		if StringInSlice(u.Repository, deployedImagesList) == false {
+		if StringInSlice(u.Path, deployedImagesList) == false {
 			deployedImagesList =
-				append(deployedImagesList, u.Repository)
+				append(deployedImagesList, u.Path)
 		}
 	}
 
 	// prepare registry registered list
 	registryImages := make(map[string][]*registry.RepositoryDigest)
-	for repositoryPath := range deployedImages {
+	for _, repositoryPath := range deployedImagesList {
 
 		imageInfo, err := api.GetImageDigestList(repositoryPath)
 		if err != nil {
@@ -122,10 +164,14 @@ func DetectGarbage(k8sImageList []string, skipTags []string, api registryInterfa
 	detectInfo := new(GarbageDetectInfo)
 	for _, repositoryPath := range deployedImagesList {
 		deployedTagList := deployedImages[repositoryPath]
+		deployedDigestList := deployedDigests[repositoryPath]
+		policy := policySet.ForRepository(repositoryPath)
 		detectItem := &GarbageDetectItem{
-			Repository:        repositoryPath,
-			DeployedTagList:   deployedTagList,
-			GarbageDigestList: []string{},
+			Repository:         repositoryPath,
+			DeployedTagList:    deployedTagList,
+			DeployedDigestList: deployedDigestList,
+			GarbageDigestList:  []string{},
+			Policy:             policy,
 		}
 
 		detectInfo.Items = append(detectInfo.Items, detectItem)
@@ -138,20 +184,106 @@ func DetectGarbage(k8sImageList []string, skipTags []string, api registryInterfa
 			continue
 		}
 
-		for _, digest := range imageDigestList {
-			if SliceHasItemsInSlice(digest.TagList, skipTags) {
-				continue
-			}
+		if err := detectItemGarbage(detectItem, imageDigestList, api); err != nil {
+			return nil, err
+		}
+	}
+
+	return detectInfo, nil
+}
+
+// DetectOrphans will detect repositories of registry catalog without any deployed image,
+// every digest of such repository is garbage, unless it's kept by repository policy
+func DetectOrphans(k8sImageList []string, catalog []string, policySet PolicyInterface, api garbageRegistryInterface, ignoreMissing bool) (*GarbageDetectInfo, error) {
+	if policySet == nil {
+		policySet = &RetentionPolicy{}
+	}
+
+	deployedRepositoryList := make([]string, 0)
+	for _, imageRefSpec := range k8sImageList {
+		u, err := DecodeReference(imageRefSpec)
+		if err != nil {
+			return nil, err
+		}
+		deployedRepositoryList = append(deployedRepositoryList, u.Path)
+	}
 
-			if SliceHasItemsInSlice(deployedTagList, digest.TagList) == false {
-				detectItem.GarbageDigestList =
-					append(detectItem.GarbageDigestList, digest.Name)
+	detectInfo := new(GarbageDetectInfo)
+	for _, repositoryPath := range catalog {
+		if StringInSlice(repositoryPath, deployedRepositoryList) {
+			continue
+		}
 
-				detectItem.GarbageTagList =
-					append(detectItem.GarbageTagList, digest.TagList...)
+		imageInfo, err := api.GetImageDigestList(repositoryPath)
+		if err != nil {
+			if ignoreMissing == false {
+				return nil, fmt.Errorf("Unknown image: %s", repositoryPath)
 			}
+			continue
+		}
+
+		detectItem := &GarbageDetectItem{
+			Repository:        repositoryPath,
+			GarbageDigestList: []string{},
+			Policy:            policySet.ForRepository(repositoryPath),
 		}
+
+		if err := detectItemGarbage(detectItem, imageInfo.Children, api); err != nil {
+			return nil, err
+		}
+
+		detectInfo.Items = append(detectInfo.Items, detectItem)
 	}
 
 	return detectInfo, nil
 }
+
+// split registry digests of repository, which are not deployed, into garbage and retained by item policy
+func detectItemGarbage(detectItem *GarbageDetectItem, imageDigestList []*registry.RepositoryDigest, api garbageRegistryInterface) error {
+	policy := detectItem.Policy
+	retainedList, err := retainedByAge(detectItem.Repository, imageDigestList, policy, api)
+	if err != nil {
+		return err
+	}
+
+	for _, digest := range imageDigestList {
+		if StringInSlice(digest.Name, detectItem.DeployedDigestList) || SliceHasItemsInSlice(detectItem.DeployedTagList, digest.TagList) {
+			continue
+		}
+
+		if policy.Protected || policy.IsTagKept(digest.TagList) || StringInSlice(digest.Name, retainedList) {
+			detectItem.RetainedDigestList =
+				append(detectItem.RetainedDigestList, digest.Name)
+
+			detectItem.RetainedTagList =
+				append(detectItem.RetainedTagList, digest.TagList...)
+			continue
+		}
+
+		detectItem.GarbageDigestList =
+			append(detectItem.GarbageDigestList, digest.Name)
+
+		detectItem.GarbageTagList =
+			append(detectItem.GarbageTagList, digest.TagList...)
+	}
+
+	return nil
+}
+
+// digests of repository kept by policy due to their creation time, registry is asked only if policy requires it
+func retainedByAge(repositoryPath string, imageDigestList []*registry.RepositoryDigest, policy *RetentionPolicy, api garbageRegistryInterface) ([]string, error) {
+	if !policy.IsAgeRequired() {
+		return []string{}, nil
+	}
+
+	createdList := make(map[string]time.Time)
+	for _, digest := range imageDigestList {
+		created, err := api.GetImageCreated(repositoryPath, digest.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to fetch creation time of %s@%s: %s", repositoryPath, digest.Name, err)
+		}
+		createdList[digest.Name] = created
+	}
+
+	return policy.KeptByAge(createdList, time.Now()), nil
+}
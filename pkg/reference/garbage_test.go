@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 type (
@@ -26,6 +27,11 @@ func (rm *RegistryInterfaceMock) GetImageDigestList(repo string) (*registry.Repo
 	return digestList, args.Error(1)
 }
 
+func (rm *RegistryInterfaceMock) GetImageCreated(repo, digest string) (time.Time, error) {
+	args := rm.Called(repo, digest)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
 func TestSliceHasItemsInSlice(t *testing.T) {
 	assert.True(t, SliceHasItemsInSlice([]string{"1", "2"}, []string{"2", "3"}))
 	assert.False(t, SliceHasItemsInSlice([]string{"1", "2"}, []string{"3", "4"}))
@@ -38,6 +44,24 @@ func TestRemoveDuplicates(t *testing.T) {
 	assert.Equal(t, []string{"1", "2"}, *dups)
 }
 
+func TestFilterRegistryImages(t *testing.T) {
+	imageList, err := FilterRegistryImages([]string{
+		"registry.example.com:5000/sample/repo1:1",
+		"mirror.example.com/sample/repo1:1",
+		"sample/repo1:2",
+		"registry.example.com:5000/sample/repo2@sha256:ffffffffffffffffffffffffffffffff",
+	}, "registry.example.com:5000")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"registry.example.com:5000/sample/repo1:1",
+		"registry.example.com:5000/sample/repo2@sha256:ffffffffffffffffffffffffffffffff",
+	}, imageList)
+
+	_, err = FilterRegistryImages([]string{"registry.example.com/Sample"}, "registry.example.com")
+	assert.NotNil(t, err)
+}
+
 func TestStringInSlice(t *testing.T) {
 	assert.True(t, StringInSlice("hello", []string{"world", "hello"}))
 	assert.False(t, StringInSlice("example", []string{"world", "hello"}))
@@ -101,7 +125,7 @@ func TestDetectGarbage_NormalCase(t *testing.T) {
 		"example.com:5000/sample/repo2:v27",
 	}
 
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, false)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, false)
 	assert.Nil(t, err)
 
 	assert.Len(t, garbageInfo.Items, 2)
@@ -147,13 +171,14 @@ func TestDetectGarbage_SkipTags(t *testing.T) {
 	}
 
 	// check
-	garbageInfo, err := DetectGarbage(deployedList, []string{"latest"}, registryMock, false)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{KeepTagList: []string{"latest"}}, registryMock, false)
 	assert.Nil(t, err)
 	assert.Len(t, garbageInfo.Items, 1)
 
 	garbageItem := garbageInfo.Items[0]
 	assert.Equal(t, []string{"3", "4"}, garbageItem.DeployedTagList)
 	assert.Equal(t, []string(nil), garbageItem.GarbageTagList)
+	assert.Equal(t, []string{"5", "latest"}, garbageItem.RetainedTagList)
 }
 
 //
@@ -166,7 +191,7 @@ func TestDetectGarbage_RegistryCallFailed(t *testing.T) {
 	registryMock.On("GetImageDigestList", "sample/repo").Return(nil, errors.New("Call failed"))
 
 	//
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, false)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, false)
 	assert.Error(t, err)
 	assert.Nil(t, garbageInfo)
 }
@@ -181,7 +206,7 @@ func TestDetectGarbage_RegistryCallFailedNoError(t *testing.T) {
 	registryMock.On("GetImageDigestList", "sample/repo").Return(nil, errors.New("Call failed"))
 
 	//
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, true)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, true)
 	assert.Nil(t, err)
 	assert.Len(t, garbageInfo.Items, 1)
 
@@ -208,7 +233,7 @@ func TestDetectGarbage_ImageNotFoundError(t *testing.T) {
 	registryMock.On("GetImageDigestList", "sample/unknown-repo").Return(registryList, nil)
 
 	//
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, false)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, false)
 	assert.Error(t, err)
 	assert.Nil(t, garbageInfo)
 }
@@ -230,7 +255,7 @@ func TestDetectGarbage_ImageNotFoundSkipMissing(t *testing.T) {
 	registryMock.On("GetImageDigestList", "sample/unknown-repo").Return(registryList, nil)
 
 	//
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, true)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, true)
 	assert.Nil(t, err)
 	assert.Len(t, garbageInfo.Items, 1)
 
@@ -242,14 +267,189 @@ func TestDetectGarbage_ImageNotFoundSkipMissing(t *testing.T) {
 
 func TestDetectGarbage_InvalidRefSpecPassed(t *testing.T) {
 	deployedList := []string{
-		"example.com/sample/unknown-repo",
+		"example.com/sample/unknown-repo-:latest",
 	}
 
 	registryMock := new(RegistryInterfaceMock)
 	registryMock.On("GetImageDigestList", "sample/unknown-repo").Return(nil, errors.New("Shouldn't be there"))
 
-	garbageInfo, err := DetectGarbage(deployedList, []string{}, registryMock, true)
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, true)
 	assert.Nil(t, garbageInfo)
 	assert.Error(t, err)
 	assert.Equal(t, "Invalid repository format", err.Error())
 }
+
+func TestDetectGarbage_DeployedByDigest(t *testing.T) {
+	registryList := new(registry.RepositoryDigestList)
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:11111111111111111111111111111111",
+		Path:    "sample/repo1",
+		TagList: []string{"1"},
+	})
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:22222222222222222222222222222222",
+		Path:    "sample/repo1",
+		TagList: []string{"2"},
+	})
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:33333333333333333333333333333333",
+		Path:    "sample/repo1",
+		TagList: []string{"3"},
+	})
+
+	// tag "1" is moved to another digest after deploy, digest is still in use
+	deployedList := []string{
+		"example.com/sample/repo1@sha256:11111111111111111111111111111111",
+		"example.com/sample/repo1:3@sha256:33333333333333333333333333333333",
+	}
+
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil)
+
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{}, registryMock, false)
+	assert.Nil(t, err)
+
+	garbageItem := garbageInfo.Items[0]
+	assert.Equal(t, []string{"3"}, garbageItem.DeployedTagList)
+	assert.Equal(t, []string{"sha256:11111111111111111111111111111111", "sha256:33333333333333333333333333333333"}, garbageItem.DeployedDigestList)
+	assert.Equal(t, []string{"sha256:22222222222222222222222222222222"}, garbageItem.GarbageDigestList)
+}
+
+// registry answer with digests "1".."5" tagged the same way, digest "5" is the most recent one
+func newRetentionRegistryMock(now time.Time) *RegistryInterfaceMock {
+	registryList := new(registry.RepositoryDigestList)
+	registryMock := new(RegistryInterfaceMock)
+	for i, tag := range []string{"1", "2", "release-3", "4", "5"} {
+		digest := "sha256:digest-" + tag
+		registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+			Name:    digest,
+			Path:    "sample/repo1",
+			TagList: []string{tag},
+		})
+		registryMock.On("GetImageCreated", "sample/repo1", digest).Return(now.Add(time.Duration(i-5)*24*time.Hour), nil)
+	}
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil)
+
+	return registryMock
+}
+
+func TestDetectGarbage_KeepLast(t *testing.T) {
+	registryMock := newRetentionRegistryMock(time.Now())
+	deployedList := []string{"example.com/sample/repo1:1"}
+
+	garbageInfo, err := DetectGarbage(deployedList, &RetentionPolicy{KeepLast: 2}, registryMock, false)
+	assert.Nil(t, err)
+
+	garbageItem := garbageInfo.Items[0]
+	assert.Equal(t, []string{"2", "release-3"}, garbageItem.GarbageTagList)
+	assert.Equal(t, []string{"sha256:digest-4", "sha256:digest-5"}, garbageItem.RetainedDigestList)
+}
+
+func TestDetectGarbage_KeepYoungerThan(t *testing.T) {
+	registryMock := newRetentionRegistryMock(time.Now())
+	deployedList := []string{"example.com/sample/repo1:1"}
+
+	policy := &RetentionPolicy{KeepYoungerThan: 60 * time.Hour}
+	garbageInfo, err := DetectGarbage(deployedList, policy, registryMock, false)
+	assert.Nil(t, err)
+
+	garbageItem := garbageInfo.Items[0]
+	assert.Equal(t, []string{"2", "release-3"}, garbageItem.GarbageTagList)
+	assert.Equal(t, []string{"4", "5"}, garbageItem.RetainedTagList)
+}
+
+func TestDetectGarbage_KeepTagRegex(t *testing.T) {
+	registryMock := newRetentionRegistryMock(time.Now())
+	deployedList := []string{"example.com/sample/repo1:1"}
+
+	policy, err := NewRetentionPolicy([]string{"5"}, []string{"^release-"}, 0, 0)
+	assert.Nil(t, err)
+
+	garbageInfo, err := DetectGarbage(deployedList, policy, registryMock, false)
+	assert.Nil(t, err)
+
+	garbageItem := garbageInfo.Items[0]
+	assert.Equal(t, []string{"2", "4"}, garbageItem.GarbageTagList)
+	assert.Equal(t, []string{"release-3", "5"}, garbageItem.RetainedTagList)
+	registryMock.AssertNotCalled(t, "GetImageCreated", "sample/repo1", "sha256:digest-1")
+}
+
+func TestDetectGarbage_CreationTimeFailed(t *testing.T) {
+	registryList := new(registry.RepositoryDigestList)
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:digest-1",
+		Path:    "sample/repo1",
+		TagList: []string{"1"},
+	})
+
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil)
+	registryMock.On("GetImageCreated", "sample/repo1", "sha256:digest-1").Return(time.Time{}, errors.New("Not Found"))
+
+	garbageInfo, err := DetectGarbage([]string{"example.com/sample/repo1:2"}, &RetentionPolicy{KeepLast: 1}, registryMock, false)
+	assert.Nil(t, garbageInfo)
+	assert.EqualError(t, err, "Unable to fetch creation time of sample/repo1@sha256:digest-1: Not Found")
+}
+
+func TestDetectGarbage_PolicySet(t *testing.T) {
+	registryMock := newRetentionRegistryMock(time.Now())
+	deployedList := []string{"example.com/sample/repo1:1"}
+
+	policySet := &PolicySet{
+		Default:        &RetentionPolicy{},
+		RepositoryList: []*RetentionPolicy{{Pattern: "sample/*", Protected: true}},
+	}
+
+	garbageInfo, err := DetectGarbage(deployedList, policySet, registryMock, false)
+	assert.Nil(t, err)
+
+	garbageItem := garbageInfo.Items[0]
+	assert.Equal(t, "sample/*", garbageItem.Policy.Pattern)
+	assert.Equal(t, []string{}, garbageItem.GarbageDigestList)
+	assert.Equal(t, []string{"2", "release-3", "4", "5"}, garbageItem.RetainedTagList)
+	registryMock.AssertNotCalled(t, "GetImageCreated", "sample/repo1", "sha256:digest-1")
+}
+
+func TestDetectOrphans(t *testing.T) {
+	registryMock := newRetentionRegistryMock(time.Now())
+
+	baseList := new(registry.RepositoryDigestList)
+	baseList.Children = append(baseList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:base-1",
+		Path:    "base/alpine",
+		TagList: []string{"3.6"},
+	})
+	registryMock.On("GetImageDigestList", "base/alpine").Return(baseList, nil)
+	registryMock.On("GetImageDigestList", "sample/removed").Return(nil, errors.New("Not Found"))
+
+	policySet := &PolicySet{
+		Default:        &RetentionPolicy{KeepTagList: []string{"5"}},
+		RepositoryList: []*RetentionPolicy{{Pattern: "base/*", Protected: true}},
+	}
+
+	// deployed repository is skipped, even if it's deployed from another registry
+	deployedList := []string{"example.com/sample/repo2:1", "other.example.com/sample/repo3:1"}
+	catalog := []string{"sample/repo1", "sample/repo2", "base/alpine", "sample/repo3"}
+
+	orphanInfo, err := DetectOrphans(deployedList, catalog, policySet, registryMock, false)
+	assert.Nil(t, err)
+	assert.Len(t, orphanInfo.Items, 2)
+
+	orphanItem := orphanInfo.Items[0]
+	assert.Equal(t, "sample/repo1", orphanItem.Repository)
+	assert.Equal(t, []string(nil), orphanItem.DeployedTagList)
+	assert.Equal(t, []string{"sha256:digest-1", "sha256:digest-2", "sha256:digest-release-3", "sha256:digest-4"}, orphanItem.GarbageDigestList)
+	assert.Equal(t, []string{"5"}, orphanItem.RetainedTagList)
+
+	orphanItem = orphanInfo.Items[1]
+	assert.Equal(t, "base/alpine", orphanItem.Repository)
+	assert.Equal(t, []string{}, orphanItem.GarbageDigestList)
+	assert.Equal(t, []string{"sha256:base-1"}, orphanItem.RetainedDigestList)
+
+	_, err = DetectOrphans(deployedList, []string{"sample/removed"}, policySet, registryMock, false)
+	assert.EqualError(t, err, "Unknown image: sample/removed")
+
+	orphanInfo, err = DetectOrphans(deployedList, []string{"sample/removed"}, policySet, registryMock, true)
+	assert.Nil(t, err)
+	assert.Len(t, orphanInfo.Items, 0)
+}
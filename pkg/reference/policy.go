@@ -0,0 +1,272 @@
+package reference
+
+import (
+	"fmt"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// PolicyInterface resolves retention policy of repository
+	PolicyInterface interface {
+		ForRepository(repository string) *RetentionPolicy
+	}
+
+	// RetentionPolicy defines which digests are kept in registry, even if they are not deployed
+	RetentionPolicy struct {
+		Pattern          string           // repository pattern policy is defined for, empty for default policy
+		Protected        bool             // every digest is kept
+		KeepTagList      []string         // exact tags, e.g. "latest"
+		KeepTagRegexList []*regexp.Regexp // tags matching any of patterns, e.g. "^release-"
+		KeepLast         int              // number of most recent digests of repository, 0 to disable
+		KeepYoungerThan  time.Duration    // digests pushed within duration, 0 to disable
+	}
+
+	// PolicySet is list of repository policies, first policy with matching pattern wins,
+	// default policy is used for other repositories
+	PolicySet struct {
+		Default        *RetentionPolicy
+		RepositoryList []*RetentionPolicy
+	}
+
+	// policy file structure
+	policyFile struct {
+		Repositories []policyFileRule `yaml:"repositories"`
+	}
+
+	// policy file rule, e.g. {pattern: "feature/*", maxAge: "3d", keepLast: 1}
+	policyFileRule struct {
+		Pattern      string   `yaml:"pattern"`
+		Protect      bool     `yaml:"protect"`
+		KeepLast     int      `yaml:"keepLast"`
+		MaxAge       string   `yaml:"maxAge"`
+		KeepTags     []string `yaml:"keepTags"`
+		KeepTagRegex []string `yaml:"keepTagRegex"`
+	}
+)
+
+// NewRetentionPolicy creates policy, tag patterns are compiled as regular expressions
+func NewRetentionPolicy(keepTagList, keepTagRegexList []string, keepLast int, keepYoungerThan time.Duration) (*RetentionPolicy, error) {
+	if keepLast < 0 {
+		return nil, fmt.Errorf("Invalid number of kept digests: %d", keepLast)
+	}
+	if keepYoungerThan < 0 {
+		return nil, fmt.Errorf("Invalid age of kept digests: %s", keepYoungerThan)
+	}
+
+	policy := &RetentionPolicy{
+		KeepTagList:      keepTagList,
+		KeepTagRegexList: make([]*regexp.Regexp, 0),
+		KeepLast:         keepLast,
+		KeepYoungerThan:  keepYoungerThan,
+	}
+
+	for _, pattern := range keepTagRegexList {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid tag pattern %q: %s", pattern, err)
+		}
+		policy.KeepTagRegexList = append(policy.KeepTagRegexList, re)
+	}
+
+	return policy, nil
+}
+
+// ForRepository return policy itself, single policy is applied to every repository
+func (p *RetentionPolicy) ForRepository(repository string) *RetentionPolicy {
+	if p == nil {
+		return &RetentionPolicy{}
+	}
+	return p
+}
+
+// IsTagKept check any of tags is kept by exact tag list or by tag patterns
+func (p *RetentionPolicy) IsTagKept(tagList []string) bool {
+	if SliceHasItemsInSlice(tagList, p.KeepTagList) {
+		return true
+	}
+
+	for _, tag := range tagList {
+		for _, re := range p.KeepTagRegexList {
+			if re.MatchString(tag) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsAgeRequired check policy depends on digests creation time
+func (p *RetentionPolicy) IsAgeRequired() bool {
+	return !p.Protected && (p.KeepLast > 0 || p.KeepYoungerThan > 0)
+}
+
+// KeptByAge return digests kept by creation time: KeepLast most recent ones and ones younger than KeepYoungerThan
+func (p *RetentionPolicy) KeptByAge(createdList map[string]time.Time, now time.Time) []string {
+	digestList := make([]string, 0, len(createdList))
+	for digest := range createdList {
+		digestList = append(digestList, digest)
+	}
+
+	// most recent first, digest name makes order stable
+	sort.Slice(digestList, func(i, j int) bool {
+		left, right := createdList[digestList[i]], createdList[digestList[j]]
+		if left.Equal(right) {
+			return digestList[i] < digestList[j]
+		}
+		return left.After(right)
+	})
+
+	keptList := make([]string, 0)
+	for i, digest := range digestList {
+		isRecent := i < p.KeepLast
+		isYoung := p.KeepYoungerThan > 0 && now.Sub(createdList[digest]) < p.KeepYoungerThan
+		if isRecent || isYoung {
+			keptList = append(keptList, digest)
+		}
+	}
+
+	return keptList
+}
+
+// String return human readable policy description
+func (p *RetentionPolicy) String() string {
+	description := "default"
+	if p.Pattern != "" {
+		description = fmt.Sprintf("pattern %q", p.Pattern)
+	}
+	if p.Protected {
+		return description + ", protected"
+	}
+
+	patternList := make([]string, 0)
+	for _, re := range p.KeepTagRegexList {
+		patternList = append(patternList, re.String())
+	}
+
+	return fmt.Sprintf("%s, keep-last=%d, keep-younger-than=%s, keep-tag=%v, keep-tag-regex=%v",
+		description, p.KeepLast, p.KeepYoungerThan, p.KeepTagList, patternList)
+}
+
+// LoadPolicySet read repository policies from yaml file, repository patterns are matched against
+// repository path (e.g. "base/*"), defaultPolicy is applied to repositories not matching any pattern
+func LoadPolicySet(filename string, defaultPolicy *RetentionPolicy) (*PolicySet, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &policyFile{}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
+	policySet := &PolicySet{
+		Default:        defaultPolicy,
+		RepositoryList: make([]*RetentionPolicy, 0),
+	}
+
+	for _, rule := range file.Repositories {
+		policy, err := rule.toPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		policySet.RepositoryList = append(policySet.RepositoryList, policy)
+	}
+
+	return policySet, nil
+}
+
+// ForRepository return policy of first matching pattern, default policy if there is no match
+func (s *PolicySet) ForRepository(repository string) *RetentionPolicy {
+	for _, policy := range s.RepositoryList {
+		if matchRepository(policy.Pattern, repository) {
+			return policy
+		}
+	}
+
+	return s.Default.ForRepository(repository)
+}
+
+// match repository path with pattern segment by segment, "*" doesn't match across "/",
+// "**" segment matches any number of segments, e.g. "base/**" matches "base/php/fpm"
+func matchRepository(pattern, repository string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(repository, "/"))
+}
+
+func matchSegments(patternList, segmentList []string) bool {
+	if len(patternList) == 0 {
+		return len(segmentList) == 0
+	}
+
+	if patternList[0] == "**" {
+		for i := 0; i <= len(segmentList); i++ {
+			if matchSegments(patternList[1:], segmentList[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segmentList) == 0 {
+		return false
+	}
+	if isMatched, _ := path.Match(patternList[0], segmentList[0]); !isMatched {
+		return false
+	}
+	return matchSegments(patternList[1:], segmentList[1:])
+}
+
+// build policy from file rule, pattern is validated
+func (r policyFileRule) toPolicy() (*RetentionPolicy, error) {
+	if r.Pattern == "" {
+		return nil, fmt.Errorf("Repository pattern is required")
+	}
+	for _, segment := range strings.Split(r.Pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("Invalid repository pattern %q: %s", r.Pattern, err)
+		}
+	}
+
+	maxAge, err := parseAge(r.MaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", r.Pattern, err)
+	}
+
+	policy, err := NewRetentionPolicy(r.KeepTags, r.KeepTagRegex, r.KeepLast, maxAge)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", r.Pattern, err)
+	}
+	policy.Pattern = r.Pattern
+	policy.Protected = r.Protect
+
+	return policy, nil
+}
+
+// parse duration, days are supported in addition to time.ParseDuration units, e.g. "3d"
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("Invalid age: %s", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid age: %s", age)
+	}
+	return duration, nil
+}
@@ -0,0 +1,109 @@
+package reference
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewRetentionPolicy(t *testing.T) {
+	policy, err := NewRetentionPolicy([]string{"latest"}, []string{"^release-", "^v[0-9]+$"}, 5, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "default, keep-last=5, keep-younger-than=1h0m0s, keep-tag=[latest], keep-tag-regex=[^release- ^v[0-9]+$]", policy.String())
+	assert.True(t, policy.IsAgeRequired())
+
+	assert.True(t, policy.IsTagKept([]string{"42", "latest"}))
+	assert.True(t, policy.IsTagKept([]string{"release-1.0"}))
+	assert.True(t, policy.IsTagKept([]string{"v12"}))
+	assert.False(t, policy.IsTagKept([]string{"v12-rc1", "master"}))
+}
+
+func TestNewRetentionPolicy_Invalid(t *testing.T) {
+	_, err := NewRetentionPolicy(nil, []string{"release-("}, 0, 0)
+	assert.Error(t, err)
+
+	_, err = NewRetentionPolicy(nil, nil, -1, 0)
+	assert.EqualError(t, err, "Invalid number of kept digests: -1")
+
+	_, err = NewRetentionPolicy(nil, nil, 0, -time.Hour)
+	assert.EqualError(t, err, "Invalid age of kept digests: -1h0m0s")
+}
+
+func TestRetentionPolicy_KeptByAge(t *testing.T) {
+	now := time.Date(2017, 7, 4, 12, 0, 0, 0, time.UTC)
+	createdList := map[string]time.Time{
+		"sha256:1": now.Add(-72 * time.Hour),
+		"sha256:2": now.Add(-48 * time.Hour),
+		"sha256:3": now.Add(-24 * time.Hour),
+		"sha256:4": now.Add(-24 * time.Hour),
+	}
+
+	policy := &RetentionPolicy{KeepLast: 1}
+	assert.Equal(t, []string{"sha256:3"}, policy.KeptByAge(createdList, now))
+
+	policy = &RetentionPolicy{KeepYoungerThan: 50 * time.Hour}
+	assert.Equal(t, []string{"sha256:3", "sha256:4", "sha256:2"}, policy.KeptByAge(createdList, now))
+
+	policy = &RetentionPolicy{}
+	assert.Equal(t, []string{}, policy.KeptByAge(createdList, now))
+	assert.False(t, policy.IsAgeRequired())
+}
+
+func TestLoadPolicySet(t *testing.T) {
+	defaultPolicy := &RetentionPolicy{KeepTagList: []string{"latest"}}
+	policySet, err := LoadPolicySet("testdata/policy.yml", defaultPolicy)
+	assert.Nil(t, err)
+	assert.Len(t, policySet.RepositoryList, 3)
+
+	policy := policySet.ForRepository("base/alpine")
+	assert.True(t, policy.Protected)
+	assert.False(t, policy.IsAgeRequired())
+	assert.Equal(t, `pattern "base/**", protected`, policy.String())
+
+	// nested repository is matched by "**"
+	assert.True(t, policySet.ForRepository("base/php/fpm").Protected)
+
+	policy = policySet.ForRepository("acme/feature-login")
+	assert.Equal(t, "*/feature-*", policy.Pattern)
+	assert.Equal(t, 72*time.Hour, policy.KeepYoungerThan)
+	assert.Equal(t, 1, policy.KeepLast)
+
+	policy = policySet.ForRepository("acme/backend")
+	assert.Equal(t, `pattern "acme/*", keep-last=10, keep-younger-than=0s, keep-tag=[latest], keep-tag-regex=[^release-]`, policy.String())
+
+	// "*" doesn't match across path separator
+	assert.Equal(t, defaultPolicy, policySet.ForRepository("acme/backend/worker"))
+	assert.Equal(t, defaultPolicy, policySet.ForRepository("acme/php/feature-login"))
+	assert.Equal(t, defaultPolicy, policySet.ForRepository("nginx"))
+}
+
+func TestMatchRepository(t *testing.T) {
+	assert.True(t, matchRepository("base/*", "base/alpine"))
+	assert.False(t, matchRepository("base/*", "base/php/fpm"))
+	assert.True(t, matchRepository("base/**", "base/php/fpm"))
+	assert.True(t, matchRepository("**/fpm", "base/php/fpm"))
+	assert.True(t, matchRepository("base/**/fpm", "base/fpm"))
+	assert.False(t, matchRepository("base/**/fpm", "base/php/cli"))
+	assert.False(t, matchRepository("base/**", "acme/base"))
+}
+
+func TestLoadPolicySet_Invalid(t *testing.T) {
+	_, err := LoadPolicySet("testdata/policy_invalid.yml", nil)
+	assert.EqualError(t, err, `testdata/policy_invalid.yml: Invalid repository pattern "acme/[": syntax error in pattern`)
+
+	_, err = LoadPolicySet("testdata/__not_exist__", nil)
+	assert.Error(t, err)
+}
+
+func TestParseAge(t *testing.T) {
+	age, err := parseAge("3d")
+	assert.Nil(t, err)
+	assert.Equal(t, 72*time.Hour, age)
+
+	age, err = parseAge("90m")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, age)
+
+	_, err = parseAge("week")
+	assert.EqualError(t, err, "Invalid age: week")
+}
@@ -0,0 +1,138 @@
+package reference
+
+import (
+	"fmt"
+	"github.com/Dalee/hitman/pkg/registry"
+	"strings"
+)
+
+var (
+	// registry answers of unknown repository: HTTP status and Docker Registry API error codes
+	notFoundErrorList = []string{"404", "not found", "name_unknown", "unknown repository"}
+)
+
+type (
+	// ImageCheckInfo holds result of image existence check
+	ImageCheckInfo struct {
+		CheckedList []string // images found in registry
+		SkippedList []string // images hosted by other registries
+		MissingList []string // images missing in registry
+	}
+)
+
+// CheckImages verify every image hosted by registryHost (e.g. "registry.example.com:5000")
+// has its tag pushed to registry, images of other registries are skipped, registry failure
+// (authorization, server or network error) is an error, not a missing image
+func CheckImages(imageList []string, registryHost string, api registryInterface) (*ImageCheckInfo, error) {
+	RemoveDuplicates(&imageList)
+
+	checkInfo := &ImageCheckInfo{
+		CheckedList: make([]string, 0),
+		SkippedList: make([]string, 0),
+		MissingList: make([]string, 0),
+	}
+
+	// every repository is requested once
+	repositoryList := make(map[string]*registry.RepositoryDigestList)
+	for _, image := range imageList {
+		u, err := DecodeReference(image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", image, err)
+		}
+
+		if u.Registry != registryHost {
+			checkInfo.SkippedList = append(checkInfo.SkippedList, image)
+			continue
+		}
+
+		digestList, ok := repositoryList[u.Path]
+		if !ok {
+			// unknown repository is reported the same way as missing tag
+			digestList, err = api.GetImageDigestList(u.Path)
+			if err != nil && !isNotFoundError(err) {
+				return nil, fmt.Errorf("Unable to check repository %s: %s", u.Path, err)
+			}
+			if digestList == nil {
+				digestList = new(registry.RepositoryDigestList)
+			}
+			repositoryList[u.Path] = digestList
+		}
+
+		if isImagePushed(digestList, u) {
+			checkInfo.CheckedList = append(checkInfo.CheckedList, image)
+		} else {
+			checkInfo.MissingList = append(checkInfo.MissingList, image)
+		}
+	}
+
+	return checkInfo, nil
+}
+
+// ResolveDigests resolve tag of every image hosted by registryHost to its manifest digest,
+// returned map is keyed by image with "image@digest" values, images of other registries
+// and images already pinned to digest are not resolved
+func ResolveDigests(imageList []string, registryHost string, api registryInterface) (map[string]string, error) {
+	pinnedList := make(map[string]string)
+	repositoryList := make(map[string]*registry.RepositoryDigestList)
+	for _, image := range imageList {
+		u, err := DecodeReference(image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", image, err)
+		}
+		if u.Registry != registryHost || u.Digest != "" {
+			continue
+		}
+
+		digestList, ok := repositoryList[u.Path]
+		if !ok {
+			digestList, err = api.GetImageDigestList(u.Path)
+			if err != nil || digestList == nil {
+				return nil, fmt.Errorf("Unknown image: %s", u.Path)
+			}
+			repositoryList[u.Path] = digestList
+		}
+
+		digest := findTagDigest(digestList, u.Tag)
+		if digest == "" {
+			return nil, fmt.Errorf("Unknown tag: %s", image)
+		}
+		pinnedList[image] = image + "@" + digest
+	}
+
+	return pinnedList, nil
+}
+
+// digest of manifest tagged with tag, empty string if tag is not found
+func findTagDigest(digestList *registry.RepositoryDigestList, tag string) string {
+	for _, digest := range digestList.Children {
+		if StringInSlice(tag, digest.TagList) {
+			return digest.Name
+		}
+	}
+	return ""
+}
+
+// check digest of image, or its tag if image is not pinned to digest, is pushed to registry
+func isImagePushed(digestList *registry.RepositoryDigestList, u *ImageReference) bool {
+	if u.Digest == "" {
+		return findTagDigest(digestList, u.Tag) != ""
+	}
+
+	for _, digest := range digestList.Children {
+		if digest.Name == u.Digest {
+			return true
+		}
+	}
+	return false
+}
+
+// check registry error is an answer about unknown repository
+func isNotFoundError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, pattern := range notFoundErrorList {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
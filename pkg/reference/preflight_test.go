@@ -0,0 +1,108 @@
+package reference
+
+import (
+	"errors"
+	"github.com/Dalee/hitman/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckImages(t *testing.T) {
+	registryList := new(registry.RepositoryDigestList)
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		Path:    "sample/repo1",
+		TagList: []string{"1", "latest"},
+	})
+
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil).Once()
+	registryMock.On("GetImageDigestList", "sample/repo2").Return(nil, errors.New("not found")).Once()
+
+	checkInfo, err := CheckImages([]string{
+		"registry.example.com:5000/sample/repo1:1",
+		"registry.example.com:5000/sample/repo1:2",
+		"registry.example.com:5000/sample/repo1:1",
+		"registry.example.com:5000/sample/repo2:1",
+		"registry.example.com:5000/sample/repo1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"registry.example.com:5000/sample/repo1:1@sha256:ffffffffffffffffffffffffffffffff",
+		"docker.io/library/nginx:1.13",
+	}, "registry.example.com:5000", registryMock)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"registry.example.com:5000/sample/repo1:1",
+		"registry.example.com:5000/sample/repo1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+	}, checkInfo.CheckedList)
+	assert.Equal(t, []string{
+		"registry.example.com:5000/sample/repo1:2",
+		"registry.example.com:5000/sample/repo2:1",
+		"registry.example.com:5000/sample/repo1:1@sha256:ffffffffffffffffffffffffffffffff",
+	}, checkInfo.MissingList)
+	assert.Equal(t, []string{"docker.io/library/nginx:1.13"}, checkInfo.SkippedList)
+	registryMock.AssertExpectations(t)
+}
+
+func TestCheckImages_RegistryFailed(t *testing.T) {
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(nil, errors.New("GET /v2/sample/repo1/tags/list failed: 401 Unauthorized")).Once()
+
+	_, err := CheckImages([]string{"registry.example.com:5000/sample/repo1:1"}, "registry.example.com:5000", registryMock)
+	assert.EqualError(t, err, "Unable to check repository sample/repo1: GET /v2/sample/repo1/tags/list failed: 401 Unauthorized")
+	registryMock.AssertExpectations(t)
+}
+
+func TestCheckImages_NotFoundAnswers(t *testing.T) {
+	for _, message := range []string{"404 Not Found", "NAME_UNKNOWN: repository name not known to registry"} {
+		assert.True(t, isNotFoundError(errors.New(message)))
+	}
+	for _, message := range []string{"503 Service Unavailable", "dial tcp: connection refused"} {
+		assert.False(t, isNotFoundError(errors.New(message)))
+	}
+}
+
+func TestCheckImages_InvalidReference(t *testing.T) {
+	registryMock := new(RegistryInterfaceMock)
+
+	_, err := CheckImages([]string{"registry.example.com:5000/Sample/Repo1:1"}, "registry.example.com:5000", registryMock)
+	assert.NotNil(t, err)
+}
+
+func TestResolveDigests(t *testing.T) {
+	registryList := new(registry.RepositoryDigestList)
+	registryList.Children = append(registryList.Children, &registry.RepositoryDigest{
+		Name:    "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		Path:    "sample/repo1",
+		TagList: []string{"1", "latest"},
+	})
+
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil).Once()
+
+	pinnedList, err := ResolveDigests([]string{
+		"registry.example.com/sample/repo1:1",
+		"registry.example.com/sample/repo1:latest",
+		"registry.example.com/sample/repo1:1@sha256:eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee",
+		"docker.io/library/nginx:1.13",
+	}, "registry.example.com", registryMock)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"registry.example.com/sample/repo1:1":      "registry.example.com/sample/repo1:1@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"registry.example.com/sample/repo1:latest": "registry.example.com/sample/repo1:latest@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	}, pinnedList)
+	registryMock.AssertExpectations(t)
+}
+
+func TestResolveDigests_Missing(t *testing.T) {
+	registryList := new(registry.RepositoryDigestList)
+	registryMock := new(RegistryInterfaceMock)
+	registryMock.On("GetImageDigestList", "sample/repo1").Return(registryList, nil)
+	registryMock.On("GetImageDigestList", "sample/repo2").Return(nil, errors.New("not found"))
+
+	_, err := ResolveDigests([]string{"registry.example.com/sample/repo1:1"}, "registry.example.com", registryMock)
+	assert.EqualError(t, err, "Unknown tag: registry.example.com/sample/repo1:1")
+
+	_, err = ResolveDigests([]string{"registry.example.com/sample/repo2:1"}, "registry.example.com", registryMock)
+	assert.EqualError(t, err, "Unknown image: sample/repo2")
+}
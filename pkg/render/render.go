@@ -0,0 +1,144 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+)
+
+type (
+	// Values is a tree of template values, available in template as .Values
+	Values map[string]interface{}
+
+	// template root object
+	templateData struct {
+		Values Values
+		Env    map[string]string
+	}
+)
+
+// NewValues merge values files in order and apply "key.path=value" overrides on top of them
+func NewValues(valuesFileList []string, setList []string) (Values, error) {
+	values := make(Values)
+	for _, filename := range valuesFileList {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		fileValues := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &fileValues); err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		mergeValues(values, fileValues)
+	}
+
+	for _, expr := range setList {
+		if err := values.Set(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// Set apply "key.path=value" expression, nested maps are created as needed, value is always a string
+func (v Values) Set(expr string) error {
+	parts := strings.SplitN(expr, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid value %q, must be key=value", expr)
+	}
+
+	keyList := strings.Split(parts[0], ".")
+	current := map[string]interface{}(v)
+	for _, key := range keyList[:len(keyList)-1] {
+		if key == "" {
+			return fmt.Errorf("invalid key %q", parts[0])
+		}
+
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+
+	current[keyList[len(keyList)-1]] = parts[1]
+	return nil
+}
+
+// merge src into dst recursively, values of src win
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, isSrcMap := value.(map[string]interface{})
+		dstMap, isDstMap := dst[key].(map[string]interface{})
+		if isSrcMap && isDstMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// environment variables as map
+func environment() map[string]string {
+	env := make(map[string]string)
+	for _, item := range os.Environ() {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+	return env
+}
+
+// helper functions available in template
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// {{ env "NAME" }}, empty string for unset variable
+		"env": os.Getenv,
+
+		// {{ index .Values "tag" | default "latest" }}
+		"default": func(fallback, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+
+		// {{ required "image tag is not set" .Values.tag }}
+		"required": func(message string, value interface{}) (interface{}, error) {
+			if value == nil || value == "" {
+				return nil, errors.New(message)
+			}
+			return value, nil
+		},
+
+		// {{ .Values.name | quote }}
+		"quote": func(value interface{}) string {
+			return fmt.Sprintf("%q", fmt.Sprint(value))
+		},
+	}
+}
+
+// Render execute data as Go text/template, values are available as .Values, environment as .Env,
+// referencing missing key of .Values or .Env is an error
+func Render(name string, data []byte, values Values) ([]byte, error) {
+	tpl, err := template.New(name).Funcs(templateFuncs()).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, &templateData{Values: values, Env: environment()}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
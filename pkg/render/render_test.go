@@ -0,0 +1,66 @@
+package render
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestNewValues(t *testing.T) {
+	values, err := NewValues(
+		[]string{"testdata/values.yml", "testdata/values-production.yml"},
+		[]string{"image.tag=1.2", "ingress.host=example.com"},
+	)
+	assert.Nil(t, err)
+
+	image := values["image"].(map[string]interface{})
+	assert.Equal(t, "registry.example.com/backend", image["repository"])
+	assert.Equal(t, "1.2", image["tag"])
+	assert.Equal(t, float64(5), values["replicas"])
+	assert.Equal(t, "example.com", values["ingress"].(map[string]interface{})["host"])
+}
+
+func TestNewValues_Errors(t *testing.T) {
+	_, err := NewValues([]string{"testdata/missing.yml"}, nil)
+	assert.NotNil(t, err)
+
+	_, err = NewValues(nil, []string{"image.tag"})
+	assert.NotNil(t, err)
+
+	_, err = NewValues(nil, []string{"image..tag=1"})
+	assert.NotNil(t, err)
+}
+
+func TestRender(t *testing.T) {
+	os.Setenv("FUSE_RENDER_TEST", "staging")
+	defer os.Unsetenv("FUSE_RENDER_TEST")
+
+	values, err := NewValues([]string{"testdata/values.yml"}, nil)
+	assert.Nil(t, err)
+
+	data := []byte(`image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+replicas: {{ .Values.replicas }}
+env: {{ .Env.FUSE_RENDER_TEST }}
+namespace: {{ env "FUSE_RENDER_TEST" | quote }}
+host: {{ index .Values "host" | default "localhost" }}
+`)
+	output, err := Render("deployment.yml", data, values)
+	assert.Nil(t, err)
+	assert.Equal(t, `image: registry.example.com/backend:1.0
+replicas: 2
+env: staging
+namespace: "staging"
+host: localhost
+`, string(output))
+}
+
+func TestRender_Errors(t *testing.T) {
+	_, err := Render("missing", []byte("{{ .Values.missing }}"), Values{})
+	assert.NotNil(t, err)
+
+	_, err = Render("required", []byte(`{{ required "tag is not set" .Values.tag }}`), Values{"tag": ""})
+	assert.Contains(t, err.Error(), "tag is not set")
+
+	_, err = Render("syntax", []byte("{{ .Values.tag "), Values{})
+	assert.NotNil(t, err)
+}